@@ -7,27 +7,54 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/chand1012/git2gpt/prompt"
 	l "github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 
 	"github.com/TimeSurgeLabs/ottodocs/pkg/ai"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/collection"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/git"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/routes"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
 )
 
 // apiDocsCmd represents the apiDocs command
 var apiDocsCmd = &cobra.Command{
 	Use:   "apiDocs",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
+	Short: "Document an HTTP API",
+	Long: `Document an HTTP API. By default the documentation is written as markdown to api.md.
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+The routes are extracted from the code for net/http, Gin, Echo, Chi, Fiber, Express, FastAPI and
+Flask. Each endpoint is documented from the source of its handler, the files given with --contextFiles
+and the declarations the handler refers to, as much as fits in the context of the configured model.
+Endpoints are documented at the same time, see --concurrency. For other frameworks the model is asked
+for the endpoints in the router files, or the API is documented from the whole repo.
+
+With --format openapi an OpenAPI 3.1 specification is written to openapi.yaml instead, or as JSON if
+the output file ends in .json. The specification is validated before it's written. Use --merge to
+update an existing specification, keeping the summaries and descriptions that were written by hand.
+
+With --format postman a Postman v2.1 collection is written to api.postman_collection.json, and with
+--format http a .http file for the REST Client of VS Code or JetBrains IDEs is written to api.http.
+Requests have example parameters and bodies from the documented schemas. The base URL and the
+credentials are the variables {{baseUrl}}, {{token}} and {{apiKey}}, so they can be set per environment.
+Use otto apiDocs verify to check the documentation against a running service.
+
+Example:
+otto apiDocs
+otto apiDocs -r server/routes.go
+otto apiDocs --format openapi -o openapi.json
+otto apiDocs --format openapi --merge
+otto apiDocs --format postman --base-url https://api.example.com
+otto apiDocs --format http
+`,
 	Run: run,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		if verbose {
@@ -45,10 +72,38 @@ func init() {
 	apiDocsCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Path to the output file.")
 	apiDocsCmd.Flags().StringSliceVarP(&routerFiles, "routerFiles", "r", []string{}, "Files that contain router information.")
 	apiDocsCmd.Flags().StringSliceVarP(&contextFiles, "contextFiles", "c", []string{}, "Files that contain context information.")
+	apiDocsCmd.Flags().IntVarP(&concurrency, "concurrency", "j", 0, "Number of endpoints to document at the same time. Defaults to the configured value or 4")
+	apiDocsCmd.Flags().StringVar(&apiFormat, "format", "markdown", "Output format: markdown, openapi, postman or http.")
+	apiDocsCmd.Flags().StringVar(&baseURL, "base-url", "", "Base URL of the API for postman and http output. Defaults to the first server of the specification or "+collection.DefaultBaseURL)
+	apiDocsCmd.Flags().BoolVarP(&mergeSpec, "merge", "m", false, "Merge into the existing OpenAPI specification, keeping hand written descriptions.")
+	addWriteFlags(apiDocsCmd)
+}
+
+// the formats the API can be documented in
+var apiFormats = []string{"markdown", "openapi", "postman", "http"}
+
+// the file written for each format when no output file is given
+var defaultAPIOutput = map[string]string{
+	"markdown": "api.md",
+	"openapi":  "openapi.yaml",
+	"postman":  "api.postman_collection.json",
+	"http":     "api.http",
 }
 
-func loadAllFiles(repo *prompt.GitRepo, repoPath string) []string {
-	var files []string
+// the code an API is documented from
+type apiSource struct {
+	// the files of the repo
+	repo []prompt.GitFile
+	// the files given with --routerFiles and --contextFiles
+	router  []prompt.GitFile
+	context []prompt.GitFile
+	// the most tokens of code to send with a request
+	budget int
+}
+
+// loadRepoFiles loads the files of the repo from disk
+func loadRepoFiles(repo *prompt.GitRepo, repoPath string) []prompt.GitFile {
+	var files []prompt.GitFile
 	for _, file := range repo.Files {
 		path := filepath.Join(repoPath, file.Path)
 		contents, err := utils.LoadFile(path)
@@ -56,11 +111,306 @@ func loadAllFiles(repo *prompt.GitRepo, repoPath string) []string {
 			log.Warnf("Error loading file %s: %s", path, err)
 			continue
 		}
-		contents = `# ` + file.Path + "\n\n" + contents + "\n\n---\n\n"
-		files = append(files, contents)
+		files = append(files, prompt.GitFile{Path: file.Path, Contents: contents})
 	}
 	return files
+}
+
+// loadFiles loads the files matching the patterns, with their paths relative to the repo
+func loadFiles(repoPath string, patterns []string) ([]prompt.GitFile, error) {
+	paths, err := utils.GlobAll(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []prompt.GitFile
+	for _, path := range paths {
+		contents, err := utils.LoadFile(path)
+		if err != nil {
+			log.Warnf("Error loading file %s: %s", path, err)
+			continue
+		}
+		relPath, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			relPath = path
+		}
+		files = append(files, prompt.GitFile{Path: filepath.ToSlash(relPath), Contents: contents})
+	}
+	return files, nil
+}
 
+// fitFiles formats the files for a prompt, leaving out the ones that don't fit in the budget.
+// Also reports whether any were left out.
+func fitFiles(files []prompt.GitFile, budget int) ([]string, bool) {
+	var out []string
+	tokens := 0
+	for _, file := range files {
+		text := `# ` + file.Path + "\n\n" + file.Contents + "\n\n---\n\n"
+		cost := calc.EstimateTokens(text)
+		if tokens+cost > budget {
+			return out, true
+		}
+		out = append(out, text)
+		tokens += cost
+	}
+	return out, false
+}
+
+// extractRoutes finds the routes of the frameworks Otto knows in the router files, or in the
+// whole repo if no router files were given, along with the source of their handlers
+func extractRoutes(src *apiSource, index *routes.Index) []routes.Route {
+	files := src.repo
+	if len(src.router) > 0 {
+		files = src.router
+	}
+
+	found := routes.Extract(files)
+	routes.ResolveHandlers(found, index)
+	for _, route := range found {
+		log.Debugf("Found %s (%s) at %s, handler %s", route.Endpoint(), route.Framework, route.Location(), route.Handler)
+	}
+	return found
+}
+
+// modelRoutes asks the model for the endpoints in the router files, or in the whole repo if no
+// router files were given
+func modelRoutes(src *apiSource, conf *config.Config) ([]routes.Route, error) {
+	log.Warn("No routes of a supported framework were found, asking the model for the endpoints")
+	files := src.repo
+	if len(src.router) > 0 {
+		files = src.router
+	}
+	contents, truncated := fitFiles(files, src.budget)
+	if truncated {
+		log.Warnf("The code is too large for %s, endpoints may be missed", conf.Model)
+	}
+
+	endpoints, err := ai.APIEndpoints(contents, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []routes.Route
+	for _, endpoint := range endpoints {
+		method, path, ok := strings.Cut(strings.TrimSpace(endpoint), " ")
+		if !ok {
+			log.Warnf("Skipping the endpoint %q, it has no method", endpoint)
+			continue
+		}
+		found = append(found, routes.Route{Method: strings.ToUpper(method), Path: strings.TrimSpace(path)})
+	}
+	return found, nil
+}
+
+// findRoutes finds the routes of the API. The routes are extracted from the code if possible,
+// otherwise the model is asked for the endpoints.
+func findRoutes(src *apiSource, index *routes.Index, conf *config.Config) ([]routes.Route, error) {
+	found := extractRoutes(src, index)
+	if len(found) > 0 {
+		return found, nil
+	}
+	return modelRoutes(src, conf)
+}
+
+// forEachEndpoint calls document for each route with the code to document it from, using a
+// pool of workers. Routes whose handler is known get the handler, the context files and the
+// declarations the handler refers to. The others get the context files, the router files and
+// then the rest of the repo. Returns the errors by endpoint.
+func forEachEndpoint(found []routes.Route, src *apiSource, index *routes.Index, conf *config.Config, document func(i int, route routes.Route, files []string) error) map[string]error {
+	ctx := &routes.Context{
+		Index:    index,
+		Extra:    src.context,
+		Fallback: append(append([]prompt.GitFile{}, src.router...), src.repo...),
+		Budget:   src.budget,
+	}
+
+	workers := workerCount(conf)
+	log.Debugf("Documenting %d endpoints with %d workers", len(found), workers)
+
+	var mu sync.Mutex
+	failures := map[string]error{}
+	truncated := 0
+	progress := utils.NewProgress("Documenting", len(found))
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				route := found[i]
+				files, cut := ctx.For(route)
+				err := document(i, route, files)
+
+				mu.Lock()
+				if cut {
+					truncated++
+				}
+				if err != nil {
+					log.Debugf("Error documenting endpoint %s: %s", route.Endpoint(), err)
+					failures[route.Endpoint()] = err
+					progress.Failed()
+				} else {
+					progress.Done()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range found {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	progress.Finish()
+
+	if truncated > 0 {
+		log.Warnf("The code for %d endpoints was cut to fit in the context of %s", truncated, conf.Model)
+	}
+	return failures
+}
+
+// logFailures logs the endpoints that couldn't be documented
+func logFailures(failures map[string]error) {
+	var endpoints []string
+	for endpoint := range failures {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		log.Errorf("Error documenting endpoint %s: %s", endpoint, failures[endpoint])
+	}
+}
+
+// documentMarkdown documents each endpoint of the API in markdown. If there are no routes
+// to go on, the whole API is documented from the repo in one request.
+func documentMarkdown(src *apiSource, conf *config.Config) (string, error) {
+	index := routes.NewIndex(src.repo)
+	found := extractRoutes(src, index)
+
+	if len(found) == 0 && len(src.router) == 0 && len(src.context) == 0 {
+		// This is the dumb document.
+		// No routes found and no router or context files to choose from, so its whatever the AI thinks
+		files, truncated := fitFiles(src.repo, src.budget)
+		if truncated {
+			log.Warnf("The repo is too large for %s, only part of it will be documented", conf.Model)
+		}
+
+		fmt.Println("Documenting repo...")
+		return ai.APIDocs(files, conf)
+	}
+
+	if len(found) == 0 {
+		var err error
+		found, err = modelRoutes(src, conf)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	docs := make([]string, len(found))
+	failures := forEachEndpoint(found, src, index, conf, func(i int, route routes.Route, files []string) error {
+		var err error
+		docs[i], err = ai.APIDocumentEndpoint(route.Endpoint(), files, conf)
+		return err
+	})
+	logFailures(failures)
+
+	var content string
+	for _, doc := range docs {
+		if doc != "" {
+			content += "\n\n" + doc
+		}
+	}
+	return content, nil
+}
+
+// documentOpenAPI documents each endpoint of the API as an OpenAPI operation and returns the
+// specification, merged into the existing one if requested
+func documentOpenAPI(src *apiSource, repoPath string, conf *config.Config) (*openapi.Document, error) {
+	fmt.Println("Finding endpoints...")
+	index := routes.NewIndex(src.repo)
+	found, err := findRoutes(src, index, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	doc := openapi.New(filepath.Base(absPath), "1.0.0")
+
+	type endpoint struct {
+		method, path string
+	}
+	var valid []routes.Route
+	var endpoints []endpoint
+	for _, route := range found {
+		e := route.Endpoint()
+		if route.Method == routes.AnyMethod {
+			// OpenAPI has no operation for every method, the handler usually serves GET
+			e = "GET " + route.Path
+		}
+		method, path, err := openapi.ParseEndpoint(e)
+		if err != nil {
+			log.Warn(err)
+			continue
+		}
+		valid = append(valid, route)
+		endpoints = append(endpoints, endpoint{method, path})
+	}
+
+	ops := make([]*openapi.Operation, len(valid))
+	failures := forEachEndpoint(valid, src, index, conf, func(i int, route routes.Route, files []string) error {
+		var err error
+		ops[i], err = ai.APIOperation(endpoints[i].method, endpoints[i].path, files, conf)
+		return err
+	})
+	logFailures(failures)
+
+	for i, op := range ops {
+		if op == nil {
+			continue
+		}
+		err = doc.SetOperation(endpoints[i].method, endpoints[i].path, op)
+		if err != nil {
+			log.Warn(err)
+		}
+	}
+
+	if mergeSpec {
+		if _, err := os.Stat(outputFile); err == nil {
+			existing, err := openapi.Load(outputFile)
+			if err != nil {
+				return nil, fmt.Errorf("error loading %s to merge into: %s", outputFile, err)
+			}
+			doc = openapi.Merge(existing, doc)
+		}
+	}
+
+	err = openapi.Validate(doc)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// encodeAPI encodes the specification in the output format
+func encodeAPI(doc *openapi.Document) (string, error) {
+	url := baseURL
+	if url == "" {
+		url = collection.BaseURL(doc)
+	}
+
+	switch apiFormat {
+	case "postman":
+		return collection.Postman(doc, url)
+	case "http":
+		return collection.HTTPFile(doc, url)
+	}
+	return openapi.Encode(doc, openapi.IsJSON(outputFile))
 }
 
 func run(cmd *cobra.Command, args []string) {
@@ -85,8 +435,34 @@ func run(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if !utils.Contains(apiFormats, apiFormat) {
+		log.Errorf("Error: unknown format %s, must be one of %s", apiFormat, strings.Join(apiFormats, ", "))
+		os.Exit(1)
+	}
+
+	if apiFormat != "markdown" && appendFile {
+		log.Errorf("Error: %s output can't be appended to", apiFormat)
+		os.Exit(1)
+	}
+
+	if baseURL != "" && (apiFormat == "markdown" || apiFormat == "openapi") {
+		log.Error("Error: --base-url can only be used with --format postman or http")
+		os.Exit(1)
+	}
+
+	if mergeSpec && apiFormat != "openapi" {
+		log.Error("Error: --merge can only be used with --format openapi")
+		os.Exit(1)
+	}
+
 	if outputFile == "" {
-		outputFile = "api.md"
+		outputFile = defaultAPIOutput[apiFormat]
+	}
+
+	// check if the output file exists
+	if _, err := os.Stat(outputFile); err == nil && !overwriteOriginal && !appendFile && !mergeSpec {
+		log.Errorf("Error: output file %s already exists!", outputFile)
+		os.Exit(1)
 	}
 
 	if !info.IsDir() {
@@ -106,91 +482,56 @@ func run(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// for now we'll try this with ChatGPT 4 turbo preview as
-	// it has a massive context limit
-	conf.Model = "gpt-4-turbo-preview"
-	if conf.BaseURL != "" {
-		log.Warn("Using custom models is not supported for this command. The OpenAPI API will be used.")
-		conf.BaseURL = ""
+	src := &apiSource{
+		repo:   loadRepoFiles(repo, repoPath),
+		budget: calc.GetMaxTokens(conf.Model) / 2,
+	}
+	src.router, err = loadFiles(repoPath, routerFiles)
+	if err != nil {
+		log.Errorf("Error loading router files: %s", err)
+		os.Exit(1)
+	}
+	src.context, err = loadFiles(repoPath, contextFiles)
+	if err != nil {
+		log.Errorf("Error loading context files: %s", err)
+		os.Exit(1)
 	}
 
 	var content string
-	// This is the dumb document.
-	// No router or context files to choose from, so its whatever the AI thinks
-	if len(routerFiles) == 0 && len(contextFiles) == 0 {
-		files := loadAllFiles(repo, repoPath)
-
-		fmt.Println("Documenting repo...")
-		content, err = ai.APIDocs(files, conf)
-		if err != nil {
-			panic(err)
-		}
-	} else if len(contextFiles) == 0 {
-		// if the router files are specified, but not the context files
-		// assume they want the whole repo but extract the router files
-		routerFilePaths, err := utils.GlobAll(routerFiles)
-		if err != nil {
-			panic(err)
-		}
-
-		var routerFileContents []string
-		for _, path := range routerFilePaths {
-			contents, err := utils.LoadFile(path)
-			if err != nil {
-				log.Warnf("Error loading file %s: %s", path, err)
-				continue
-			}
-			routerFileContents = append(routerFileContents, contents)
-		}
-
-		endpoints, err := ai.APIEndpoints(routerFileContents, conf)
-		if err != nil {
-			panic(err)
-		}
-
-		files := loadAllFiles(repo, repoPath)
-		fmt.Println("Documenting repo...")
-		for _, endpoint := range endpoints {
-			fmt.Printf("Documenting endpoint %s...\n", endpoint)
-			endpointContent, err := ai.APIDocumentEndpoint(endpoint, files, conf)
-			if err != nil {
-				log.Errorf("Error documenting endpoint %s: %s", endpoint, err)
-				continue
-			}
-			content += "\n\n" + endpointContent
+	if apiFormat == "markdown" {
+		content, err = documentMarkdown(src, conf)
+	} else {
+		var doc *openapi.Document
+		doc, err = documentOpenAPI(src, repoPath, conf)
+		if err == nil {
+			content, err = encodeAPI(doc)
 		}
 	}
-
-	exists := false
-	// check if the output file exists
-	if _, err := os.Stat(outputFile); err == nil {
-		if !overwriteOriginal {
-			log.Errorf("Error: output file %s already exists!", outputFile)
-			os.Exit(1)
-		}
-		exists = true
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
 	}
 
-	var file *os.File
-	if !exists {
-		// write the string to the output file
-		file, err = os.Create(outputFile)
-		if err != nil {
-			panic(err)
-		}
+	w := newWriter()
+	if appendFile {
+		err = w.AppendFile(outputFile, content)
 	} else {
-		// append if the file already exists
-		file, err = os.OpenFile(outputFile, os.O_WRONLY, 0644)
-		if err != nil {
-			panic(err)
-		}
+		err = w.WriteFile(outputFile, content)
+	}
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
 	}
 
-	_, err = file.WriteString(content)
+	err = w.Flush()
 	if err != nil {
 		log.Error(err)
 		os.Exit(1)
 	}
 
+	if !w.Writes() {
+		return
+	}
+
 	fmt.Printf("API documentation written to %s\n", outputFile)
 }
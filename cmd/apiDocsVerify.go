@@ -0,0 +1,147 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	l "github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/apiverify"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+)
+
+// the documentation verified when no file is given, in order
+var defaultSpecFiles = []string{"openapi.yaml", "openapi.yml", "openapi.json", "api.md"}
+
+// apiDocsVerifyCmd represents the apiDocs verify command
+var apiDocsVerifyCmd = &cobra.Command{
+	Use:   "verify [file]",
+	Short: "Check API documentation against a running service",
+	Long: `Check API documentation against a running service. An example request is made to each documented
+endpoint, and the status code and the shape of the JSON response are compared to the documentation.
+Undocumented statuses, missing or mistyped fields, and fields that aren't documented are reported.
+
+The documentation is read from an OpenAPI specification or from markdown written by otto apiDocs.
+If no file is given, openapi.yaml, openapi.json or api.md is used, whichever exists. Markdown is
+less precise, only the statuses it mentions and its first JSON response example are checked.
+
+Only GET and HEAD requests are made unless --all-methods is set, as other methods may change data.
+The model is not used, so no config is needed.
+
+Example:
+otto apiDocs verify --base-url http://localhost:8080
+otto apiDocs verify api.md --base-url http://localhost:8080 -H "Authorization: Bearer $TOKEN"
+otto apiDocs verify --base-url http://localhost:8080 --all-methods
+`,
+	Args: cobra.MaximumNArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			log.SetLevel(l.DebugLevel)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if baseURL == "" {
+			log.Error("Error: --base-url is required")
+			os.Exit(1)
+		}
+
+		specPath := ""
+		if len(args) > 0 {
+			specPath = args[0]
+		} else {
+			for _, name := range defaultSpecFiles {
+				if _, err := os.Stat(name); err == nil {
+					specPath = name
+					break
+				}
+			}
+			if specPath == "" {
+				log.Errorf("Error: no documentation found, expected one of %s", strings.Join(defaultSpecFiles, ", "))
+				os.Exit(1)
+			}
+		}
+
+		headers := http.Header{}
+		for _, header := range requestHeaders {
+			name, value, ok := strings.Cut(header, ":")
+			if !ok {
+				log.Errorf("Error: header %s must be in the form Name: value", header)
+				os.Exit(1)
+			}
+			headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+
+		var doc *openapi.Document
+		switch strings.ToLower(filepath.Ext(specPath)) {
+		case ".md", ".markdown":
+			contents, err := os.ReadFile(specPath)
+			if err != nil {
+				log.Errorf("Error reading %s: %s", specPath, err)
+				os.Exit(1)
+			}
+			doc = apiverify.FromMarkdown(string(contents))
+		default:
+			var err error
+			doc, err = openapi.Load(specPath)
+			if err != nil {
+				log.Errorf("Error loading %s: %s", specPath, err)
+				os.Exit(1)
+			}
+		}
+		if len(doc.Paths) == 0 {
+			log.Errorf("Error: no endpoints found in %s", specPath)
+			os.Exit(1)
+		}
+		log.Debugf("Verifying %s against %s", specPath, baseURL)
+
+		results := apiverify.Verify(doc, apiverify.Options{
+			BaseURL:    baseURL,
+			AllMethods: allMethods,
+			Headers:    headers,
+			Client:     &http.Client{Timeout: requestTimeout},
+		})
+
+		failed, skipped := 0, 0
+		for _, result := range results {
+			switch {
+			case result.Skipped != "":
+				skipped++
+				log.Debugf("SKIP %s: %s", result.Endpoint(), result.Skipped)
+			case result.Err != nil:
+				failed++
+				fmt.Printf("FAIL %s: %s\n", result.Endpoint(), result.Err)
+			case len(result.Problems) > 0:
+				failed++
+				fmt.Printf("FAIL %s (%d)\n", result.Endpoint(), result.Status)
+				for _, problem := range result.Problems {
+					fmt.Printf("  - %s\n", problem)
+				}
+			default:
+				fmt.Printf("ok   %s (%d)\n", result.Endpoint(), result.Status)
+			}
+		}
+
+		fmt.Printf("\n%d endpoints checked, %d with discrepancies, %d skipped\n", len(results)-skipped, failed, skipped)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	apiDocsCmd.AddCommand(apiDocsVerifyCmd)
+
+	apiDocsVerifyCmd.Flags().StringVar(&baseURL, "base-url", "", "Base URL of the running service, for example http://localhost:8080")
+	apiDocsVerifyCmd.Flags().BoolVar(&allMethods, "all-methods", false, "Request every method, not just GET and HEAD. This may change data!")
+	apiDocsVerifyCmd.Flags().StringArrayVarP(&requestHeaders, "header", "H", []string{}, "Header to send with every request, in the form Name: value")
+	apiDocsVerifyCmd.Flags().DurationVar(&requestTimeout, "timeout", 10*time.Second, "Timeout for each request")
+	apiDocsVerifyCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+}
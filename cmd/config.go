@@ -6,8 +6,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/docstyle"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -26,6 +28,17 @@ See here for more information on the Models available: https://platform.openai.c
 
 GitHub Tokens need access to the repo scope.
 
+Requests can be rate limited with --rpm and --tpm, which are shared by all the requests Otto makes at the same time.
+Set them to 0 to remove the limit. --concurrency sets how many files or endpoints otto docs and otto apiDocs document at once (default 4).
+
+Doc comment styles can be set per language with --docStyle language=style.
+Valid styles are: python=google|numpy|sphinx|line, javascript=jsdoc|line, typescript=tsdoc|jsdoc|line, java=javadoc|line, rust=rustdoc
+
+Files otto edit writes can be formatted with --formatter .ext=command. The file is written to the
+command's stdin and the formatted file read from its stdout, {file} is replaced with its path.
+If the formatter fails, the code is treated as invalid. Go files are always checked and formatted.
+For example: --formatter ".py=black -q -" --formatter ".ts=prettier --stdin-filepath {file}"
+
 OpenAI API Key Generation: https://platform.openai.com/account/api-keys
 GitHub Token Generation: https://github.com/settings/tokens
 `,
@@ -39,7 +52,7 @@ GitHub Token Generation: https://github.com/settings/tokens
 		}
 
 		// if none of the config options are provided, print a warning
-		if apiKey == "" && model == "" && ghToken == "" && userColor == "" && ottoColor == "" && organization == "" {
+		if apiKey == "" && model == "" && ghToken == "" && userColor == "" && ottoColor == "" && organization == "" && len(docStyles) == 0 && len(formatters) == 0 && requestsPerMinute < 0 && tokensPerMinute < 0 && concurrency == 0 {
 			log.Warn("No configuration options provided")
 			os.Exit(0)
 		}
@@ -85,6 +98,64 @@ GitHub Token Generation: https://github.com/settings/tokens
 			c.Org = organization
 		}
 
+		// rate limits of 0 remove the limit, so -1 means not provided
+		if requestsPerMinute >= 0 {
+			fmt.Println("Setting requests per minute...")
+			c.RequestsPerMinute = requestsPerMinute
+		}
+
+		if tokensPerMinute >= 0 {
+			fmt.Println("Setting tokens per minute...")
+			c.TokensPerMinute = tokensPerMinute
+		}
+
+		if concurrency != 0 {
+			if concurrency < 0 {
+				log.Errorf("Invalid concurrency: %d. Must be at least 1", concurrency)
+				os.Exit(1)
+			}
+			fmt.Println("Setting concurrency...")
+			c.Concurrency = concurrency
+		}
+
+		// if doc styles are provided, set them
+		for _, docStyle := range docStyles {
+			language, style, ok := strings.Cut(docStyle, "=")
+			if !ok {
+				log.Errorf("Invalid doc style: %s. Must be in the form language=style", docStyle)
+				os.Exit(1)
+			}
+			err = docstyle.Validate(language, style)
+			if err != nil {
+				log.Errorf("Invalid doc style: %s", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Setting %s doc style...\n", language)
+			if c.DocStyles == nil {
+				c.DocStyles = map[string]string{}
+			}
+			c.DocStyles[language] = style
+		}
+
+		// if formatters are provided, set them. An empty command removes the formatter
+		for _, formatter := range formatters {
+			ext, command, ok := strings.Cut(formatter, "=")
+			if !ok || !strings.HasPrefix(ext, ".") {
+				log.Errorf("Invalid formatter: %s. Must be in the form .ext=command", formatter)
+				os.Exit(1)
+			}
+			if c.Formatters == nil {
+				c.Formatters = map[string]string{}
+			}
+			if command == "" {
+				fmt.Printf("Removing %s formatter...\n", ext)
+				delete(c.Formatters, strings.ToLower(ext))
+				continue
+			}
+			fmt.Printf("Setting %s formatter...\n", ext)
+			c.Formatters[strings.ToLower(ext)] = command
+		}
+
 		// save the config
 		err = c.Save()
 		if err != nil {
@@ -111,4 +182,13 @@ func init() {
 	configCmd.Flags().StringVarP(&ottoColor, "ottoColor", "o", "", "Otto color for configuration")
 	// set organization
 	configCmd.Flags().StringVarP(&organization, "organization", "g", "", "Organization to use for documentation")
+	// set rate limits
+	configCmd.Flags().IntVar(&requestsPerMinute, "rpm", -1, "Maximum requests per minute to the API. 0 for unlimited")
+	configCmd.Flags().IntVar(&tokensPerMinute, "tpm", -1, "Maximum tokens per minute sent to the API. 0 for unlimited")
+	// set concurrency
+	configCmd.Flags().IntVar(&concurrency, "concurrency", 0, "Number of files or endpoints to document at the same time")
+	// set doc comment styles
+	configCmd.Flags().StringSliceVar(&docStyles, "docStyle", []string{}, "Doc comment style for a language in the form language=style")
+	// set formatters
+	configCmd.Flags().StringArrayVar(&formatters, "formatter", []string{}, "Command that formats files otto edit writes in the form .ext=command")
 }
@@ -7,11 +7,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/TimeSurgeLabs/ottodocs/pkg/ai"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/authored"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/depgraph"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/git"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/site"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/state"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/symbols"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/writer"
+	"github.com/chand1012/git2gpt/prompt"
 	l "github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 )
@@ -23,8 +33,30 @@ var docsCmd = &cobra.Command{
 	Long: `Document an entire repository of files. Specify the path to the repo as the first positional argument. This command will recursively
 search for files in the directory and document them. If a single file is specified, it will be documented.
 
+Comments written by Otto are tracked in .ottodocs/comments.json in the root of the repo. Running the
+command again replaces them instead of adding duplicates, and --strip removes them all.
+
+When overwriting files, a hash of each documented file is kept in .ottodocs/cache.json and files that
+have not changed since they were last documented are skipped. Use --since or --staged to only document
+the declarations that changed, for example in CI on every push.
+
+With --site, markdown documentation is written to a directory as a site with one page per directory of
+the repo, an index page linking them all and an mkdocs.yml, so it can be published with mkdocs build.
+
+With --overview, ARCHITECTURE.md is written to the root of the repo with an overview of the architecture,
+a map of the directories, a Mermaid diagram of the imports between them and a summary of each directory.
+Go imports are parsed, JavaScript, TypeScript and Python imports are detected heuristically.
+
+Files are documented in parallel, 4 at a time by default. Use -j to change it, or set it and the
+rate limits with otto config. Results are still written in file order.
+
 Example:
 otto docs . -i -w 
+otto docs . -i -w --since origin/main
+otto docs . --strip
+otto docs . -i -w --dry-run
+otto docs . --site site
+otto docs . --overview
 	`,
 	Aliases: []string{"d"},
 	PreRun: func(cmd *cobra.Command, args []string) {
@@ -40,12 +72,40 @@ otto docs . -i -w
 			repoPath = "."
 		}
 
+		if stripComments {
+			stripDocs(repoPath)
+			return
+		}
+
+		if sinceRef != "" && stagedOnly {
+			log.Error("Error: cannot use --since and --staged together")
+			os.Exit(1)
+		}
+
+		if overviewMode && (inlineMode || markdownMode || siteDir != "") {
+			log.Error("Error: --overview cannot be used with --inline, --markdown or --site")
+			os.Exit(1)
+		}
+
+		if siteDir != "" {
+			if inlineMode || overwriteOriginal || outputFile != "" {
+				log.Error("Error: --site cannot be used with --inline, --overwrite or --output")
+				os.Exit(1)
+			}
+			markdownMode = true
+		}
+
+		// a diff of the changes is only useful if the files would be changed
+		if (dryRun || patchFile != "") && !markdownMode && !overviewMode {
+			overwriteOriginal = true
+		}
+
 		if markdownMode && overwriteOriginal {
 			log.Error("Error: cannot overwrite original file in markdown mode")
 			os.Exit(1)
 		}
 
-		if markdownMode && outputFile == "" {
+		if markdownMode && outputFile == "" && siteDir == "" {
 			log.Error("Error: must specify an output file in markdown mode")
 			os.Exit(1)
 		}
@@ -73,6 +133,11 @@ otto docs . -i -w
 			os.Exit(1)
 		}
 
+		if (siteDir != "" || overviewMode) && !info.IsDir() {
+			log.Error("Error: --site and --overview require a directory")
+			os.Exit(1)
+		}
+
 		if info.IsDir() {
 			// make sure its a git repo
 			if !git.IsGitRepo(repoPath) {
@@ -87,80 +152,12 @@ otto docs . -i -w
 				os.Exit(1)
 			}
 
-			log.Debug("Documenting repo...")
-			for _, file := range repo.Files {
-				var contents string
-
-				path := filepath.Join(repoPath, file.Path)
-
-				if outputFile != "" {
-					fmt.Println("Documenting file", file.Path)
-				}
-
-				if chatPrompt == "" {
-					chatPrompt = "Write documentation for the following code snippet. The file name is" + file.Path + ":"
-				}
-
-				log.Debugf("Loading file %s", path)
-				fileContents, err := utils.LoadFile(path)
-				if err != nil {
-					log.Warnf("Error loading file %s: %s", path, err)
-					continue
-				}
-
-				if inlineMode || !markdownMode {
-					log.Debugf("Documenting inline file %s", path)
-					contents, err = ai.SingleFile(path, fileContents, chatPrompt, conf)
-				} else {
-					log.Debugf("Documenting markdown for %s", path)
-					contents, err = ai.Markdown(path, fileContents, chatPrompt, conf)
-				}
-
-				if err != nil {
-					log.Warnf("Error documenting file %s: %s", path, err)
-					continue
-				}
-
-				if outputFile != "" && markdownMode {
-					log.Debug("Writing markdown to file...")
-					// write the string to the output file
-					// append if the file already exists
-					file, err := os.OpenFile(outputFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
-					if err != nil {
-						log.Errorf("Error: %s", err)
-						os.Exit(1)
-					}
-
-					_, err = file.WriteString(contents)
-					if err != nil {
-						log.Errorf("Error: %s", err)
-						os.Exit(1)
-					}
-
-					file.Close()
-				} else if overwriteOriginal {
-					log.Debug("Overwriting original file...")
-					// overwrite the original file
-					// clear the contents of the file
-					file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-					if err != nil {
-						log.Errorf("Error: %s", err)
-						os.Exit(1)
-					}
-
-					// write the new contents to the file
-					_, err = file.WriteString(contents)
-					if err != nil {
-						log.Errorf("Error: %s", err)
-						os.Exit(1)
-					}
-
-					file.Close()
-				} else {
-					// print the contents to stdout
-					fmt.Println(contents)
-				}
+			if overviewMode {
+				documentOverview(repoPath, repo.Files, conf)
+				return
 			}
+
+			documentRepo(repoPath, repo.Files, conf)
 		} else {
 			fmt.Println("Documenting file...")
 			if chatPrompt == "" {
@@ -177,9 +174,33 @@ otto docs . -i -w
 				os.Exit(1)
 			}
 
+			root, err := state.Root(filePath)
+			if err != nil {
+				log.Errorf("Error: %s", err)
+				os.Exit(1)
+			}
+
+			relPath, err := state.RelPath(root, filePath)
+			if err != nil {
+				log.Errorf("Error: %s", err)
+				os.Exit(1)
+			}
+
+			comments, err := authored.Load(root)
+			if err != nil {
+				log.Errorf("Error loading Otto comments: %s", err)
+				os.Exit(1)
+			}
+
 			if inlineMode || !markdownMode {
+				only, err := changedSymbols(filePath, fileContents)
+				if err != nil {
+					log.Errorf("Error getting changed symbols: %s", err)
+					os.Exit(1)
+				}
+
 				log.Debug("Documenting inline...")
-				contents, err = ai.SingleFile(filePath, fileContents, chatPrompt, conf)
+				contents, err = documentInline(filePath, relPath, fileContents, chatPrompt, only, comments, conf)
 			} else {
 				log.Debug("Documenting markdown...")
 				contents, err = ai.Markdown(filePath, fileContents, chatPrompt, conf)
@@ -190,37 +211,615 @@ otto docs . -i -w
 				os.Exit(1)
 			}
 
+			w := newWriter()
 			if outputFile != "" {
 				log.Debug("Writing to file...")
-				// write the string to the output file
-				err = os.WriteFile(outputFile, []byte(contents), 0644)
+				err = w.WriteFile(outputFile, contents)
 				if err != nil {
 					log.Errorf("Error: %s", err)
 					os.Exit(1)
 				}
 			} else if overwriteOriginal {
 				log.Debug("Overwriting original file...")
-				// overwrite the original file
-				// clear the contents of the file
-				file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+				err = w.WriteFile(filePath, contents)
 				if err != nil {
 					log.Errorf("Error: %s", err)
 					os.Exit(1)
 				}
 
-				// write the new contents to the file
-				_, err = file.WriteString(contents)
-				if err != nil {
-					log.Errorf("Error: %s", err)
-					os.Exit(1)
+				if w.Writes() {
+					err = comments.Save(root)
+					if err != nil {
+						log.Errorf("Error saving Otto comments: %s", err)
+						os.Exit(1)
+					}
 				}
 			} else {
 				fmt.Println(contents)
 			}
+
+			err = w.Flush()
+			if err != nil {
+				log.Errorf("Error: %s", err)
+				os.Exit(1)
+			}
 		}
 	},
 }
 
+// the default number of files or endpoints documented at the same time
+const defaultConcurrency = 4
+
+// workerCount returns how many requests to make at the same time, from the
+// --concurrency flag, the config or the default
+func workerCount(conf *config.Config) int {
+	if concurrency > 0 {
+		return concurrency
+	}
+	if conf.Concurrency > 0 {
+		return conf.Concurrency
+	}
+	return defaultConcurrency
+}
+
+// a file in the repository to document
+type docJob struct {
+	index    int
+	path     string
+	relPath  string
+	contents string
+	// the Otto comments previously written into the file
+	records []authored.Comment
+	skip    bool
+}
+
+// the outcome of documenting a file
+type docResult struct {
+	index    int
+	contents string
+	// the Otto comments recorded for the file after documenting it
+	comments []authored.Comment
+	skipped  bool
+	err      error
+}
+
+// documentRepo documents the files in the repository using a pool of workers.
+// Requests share the rate limit in the config, and the results are written in
+// the same order as the files no matter which finishes first.
+func documentRepo(repoPath string, files []prompt.GitFile, conf *config.Config) {
+	root, err := state.Root(repoPath)
+	if err != nil {
+		log.Errorf("Error: %s", err)
+		os.Exit(1)
+	}
+
+	comments, err := authored.Load(root)
+	if err != nil {
+		log.Errorf("Error loading Otto comments: %s", err)
+		os.Exit(1)
+	}
+
+	cache, err := state.LoadCache(root)
+	if err != nil {
+		log.Errorf("Error loading cache: %s", err)
+		os.Exit(1)
+	}
+
+	var changed map[string]bool
+	if sinceRef != "" || stagedOnly {
+		changed, err = changedFiles(repoPath)
+		if err != nil {
+			log.Errorf("Error getting changed files: %s", err)
+			os.Exit(1)
+		}
+		log.Debugf("%d files changed", len(changed))
+	}
+
+	useCache := (inlineMode || !markdownMode) && overwriteOriginal && !noCache
+
+	var jobs []docJob
+	for _, file := range files {
+		path := filepath.Join(repoPath, file.Path)
+
+		if changed != nil {
+			absPath, err := filepath.Abs(path)
+			if err != nil || !changed[absPath] {
+				log.Debugf("Skipping unchanged file %s", path)
+				continue
+			}
+		}
+
+		relPath, err := state.RelPath(root, path)
+		if err != nil {
+			log.Warnf("Error getting path of %s: %s", path, err)
+			continue
+		}
+
+		log.Debugf("Loading file %s", path)
+		contents, err := utils.LoadFile(path)
+		if err != nil {
+			log.Warnf("Error loading file %s: %s", path, err)
+			continue
+		}
+
+		jobs = append(jobs, docJob{
+			index:    len(jobs),
+			path:     path,
+			relPath:  relPath,
+			contents: contents,
+			records:  comments[relPath],
+			skip:     useCache && cache.Unchanged(relPath, contents),
+		})
+	}
+
+	workers := workerCount(conf)
+	log.Debugf("Documenting %d files with %d workers", len(jobs), workers)
+
+	// the workers only use what is in their job, the comments and cache are
+	// updated here as the results are written
+	work := make(chan docJob)
+	results := make(chan docResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range work {
+				results <- documentJob(job, conf)
+			}
+		}()
+	}
+
+	go func() {
+		for _, job := range jobs {
+			work <- job
+		}
+		close(work)
+		wg.Wait()
+		close(results)
+	}()
+
+	w := newWriter()
+	var siteDocs []site.FileDoc
+	progress := utils.NewProgress("Documenting", len(jobs))
+	failures := map[string]error{}
+	pending := map[int]docResult{}
+	next := 0
+	for result := range results {
+		job := jobs[result.index]
+		switch {
+		case result.err != nil:
+			log.Debugf("Error documenting file %s: %s", job.path, result.err)
+			failures[job.path] = result.err
+			progress.Failed()
+		case result.skipped:
+			progress.Skipped()
+		default:
+			progress.Done()
+		}
+
+		// write the results that are ready in file order
+		pending[result.index] = result
+		for {
+			result, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if siteDir != "" && result.err == nil {
+				siteDocs = append(siteDocs, site.FileDoc{Path: jobs[next].relPath, Markdown: result.contents})
+			} else {
+				writeDocResult(w, jobs[next], result, root, comments, cache, useCache)
+			}
+			next++
+		}
+	}
+	progress.Finish()
+
+	if siteDir != "" {
+		log.Debugf("Writing site to %s...", siteDir)
+		pages := site.Build(filepath.Base(root), siteDocs)
+		// sorted so dry runs and patches are the same every time
+		var sitePaths []string
+		for sitePath := range pages {
+			sitePaths = append(sitePaths, sitePath)
+		}
+		sort.Strings(sitePaths)
+		for _, sitePath := range sitePaths {
+			fullPath := filepath.Join(siteDir, filepath.FromSlash(sitePath))
+			err = w.WriteFile(fullPath, pages[sitePath])
+			if err != nil {
+				log.Errorf("Error writing site: %s", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		log.Errorf("Error: %s", err)
+		os.Exit(1)
+	}
+
+	done, failed, skipped := progress.Counts()
+	fmt.Printf("Documented %d files, %d failed, %d skipped\n", done, failed, skipped)
+	if len(failures) > 0 {
+		var paths []string
+		for path := range failures {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			log.Errorf("Error documenting file %s: %s", path, failures[path])
+		}
+	}
+}
+
+// documentJob documents a single file of the repository
+func documentJob(job docJob, conf *config.Config) docResult {
+	result := docResult{index: job.index}
+	fileContents := job.contents
+
+	if job.skip {
+		log.Debugf("Skipping %s, it has not changed since it was last documented", job.path)
+		result.skipped = true
+		return result
+	}
+
+	prompt := chatPrompt
+	if prompt == "" {
+		prompt = "Write documentation for the following code snippet. The file name is " + job.relPath + ":"
+	}
+
+	if inlineMode || !markdownMode {
+		only, err := changedSymbols(job.path, fileContents)
+		if err != nil {
+			result.err = fmt.Errorf("could not get changed symbols: %s", err)
+			return result
+		}
+
+		comments := authored.Comments{}
+		comments.Add(job.relPath, job.records)
+
+		log.Debugf("Documenting inline file %s", job.path)
+		result.contents, result.err = documentInline(job.path, job.relPath, fileContents, prompt, only, comments, conf)
+		result.comments = comments[job.relPath]
+	} else {
+		log.Debugf("Documenting markdown for %s", job.path)
+		result.contents, result.err = ai.Markdown(job.path, fileContents, prompt, conf)
+	}
+
+	return result
+}
+
+// writeDocResult writes the documented file and saves the state that goes with it
+func writeDocResult(w *writer.Writer, job docJob, result docResult, root string, comments authored.Comments, cache state.Cache, useCache bool) {
+	if result.err != nil || result.skipped {
+		return
+	}
+
+	if outputFile != "" && markdownMode {
+		log.Debugf("Writing markdown for %s to file...", job.path)
+		err := w.AppendFile(outputFile, result.contents)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+	} else if overwriteOriginal {
+		log.Debugf("Overwriting %s...", job.path)
+		err := w.WriteFile(job.path, result.contents)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		// the state only changes if the file did
+		if !w.Writes() {
+			return
+		}
+
+		delete(comments, job.relPath)
+		comments.Add(job.relPath, result.comments)
+		err = comments.Save(root)
+		if err != nil {
+			log.Errorf("Error saving Otto comments: %s", err)
+			os.Exit(1)
+		}
+
+		if useCache {
+			cache.Set(job.relPath, result.contents)
+			err = cache.Save(root)
+			if err != nil {
+				log.Errorf("Error saving cache: %s", err)
+				os.Exit(1)
+			}
+		}
+	} else {
+		// print the contents to stdout
+		fmt.Println(result.contents)
+	}
+}
+
+// documentOverview writes ARCHITECTURE.md, with a map of the directories of the repository, a diagram of
+// the dependencies between them and a summary of each. Directories are summarized from the deepest up so
+// each summary can build on the ones below it.
+func documentOverview(repoPath string, files []prompt.GitFile, conf *config.Config) {
+	output := outputFile
+	if output == "" {
+		output = filepath.Join(repoPath, "ARCHITECTURE.md")
+		if _, err := os.Stat(output); err == nil && !overwriteOriginal && !dryRun && patchFile == "" {
+			log.Errorf("Error: %s already exists! Use --overwrite to replace it", output)
+			os.Exit(1)
+		}
+	}
+
+	root, err := filepath.Abs(repoPath)
+	if err != nil {
+		log.Errorf("Error: %s", err)
+		os.Exit(1)
+	}
+
+	// go.mod is needed to tell the imports of the repo's own packages apart
+	files = addRootFiles(repoPath, files, "go.mod")
+
+	graph := depgraph.Build(files)
+	contents := map[string]string{}
+	for _, file := range files {
+		contents[filepath.ToSlash(filepath.Clean(file.Path))] = file.Contents
+	}
+
+	paths := graph.Paths()
+	sort.SliceStable(paths, func(i, j int) bool {
+		return strings.Count(paths[i], "/") > strings.Count(paths[j], "/")
+	})
+
+	summaries := map[string]string{}
+	failures := map[string]error{}
+	progress := utils.NewProgress("Summarizing", len(paths))
+	for _, p := range paths {
+		module := graph.Modules[p]
+		// a directory that only holds another directory doesn't need its own summary
+		if len(module.Files) == 0 && len(module.Children) < 2 {
+			progress.Skipped()
+			continue
+		}
+
+		log.Debugf("Summarizing %s", p)
+		summary, err := ai.SummarizeModule(module, contents, summaries, conf)
+		if err != nil {
+			failures[p] = err
+			progress.Failed()
+			continue
+		}
+		summaries[p] = strings.TrimSpace(summary)
+		progress.Done()
+	}
+	progress.Finish()
+
+	for _, p := range graph.Paths() {
+		if err, ok := failures[p]; ok {
+			log.Warnf("Error summarizing %s: %s", p, err)
+		}
+	}
+
+	log.Debug("Writing overview...")
+	overview, err := ai.ArchitectureOverview(graph, summaries, conf)
+	if err != nil {
+		log.Errorf("Error writing overview: %s", err)
+		os.Exit(1)
+	}
+
+	w := newWriter()
+	err = w.WriteFile(output, graph.Architecture(filepath.Base(root), overview, summaries))
+	if err != nil {
+		log.Errorf("Error: %s", err)
+		os.Exit(1)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		log.Errorf("Error: %s", err)
+		os.Exit(1)
+	}
+
+	if w.Writes() {
+		fmt.Printf("Architecture overview written to %s\n", output)
+	}
+}
+
+// addRootFiles adds the files in the root of the repository to the files if they exist. Files that
+// aren't code, like go.mod, are often ignored but are still needed to understand the repository.
+func addRootFiles(repoPath string, files []prompt.GitFile, names ...string) []prompt.GitFile {
+	for _, name := range names {
+		found := false
+		for _, file := range files {
+			if filepath.Clean(file.Path) == name {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		contents, err := utils.LoadFile(filepath.Join(repoPath, name))
+		if err == nil {
+			files = append(files, prompt.GitFile{Path: name, Contents: contents})
+		}
+	}
+	return files
+}
+
+// documentInline refreshes the doc comments in a file. Comments from previous runs
+// are removed first so they are replaced rather than stacked, and the new
+// ones are recorded in comments. If only is not nil, just those symbols are documented.
+func documentInline(path, relPath, contents, prompt string, only map[string]bool, comments authored.Comments, conf *config.Config) (string, error) {
+	if only != nil && len(only) == 0 {
+		log.Debugf("No changed declarations in %s", path)
+		return contents, nil
+	}
+
+	contents, removed, err := comments.Strip(relPath, contents, only)
+	if err != nil {
+		return "", err
+	}
+	log.Debugf("Removed %d previous Otto comments from %s", removed, path)
+
+	contents, written, err := ai.SingleFileSymbols(path, contents, prompt, only, conf)
+	if err != nil {
+		return "", err
+	}
+
+	var records []authored.Comment
+	for _, comment := range written {
+		records = append(records, authored.NewComment(comment.Symbol, comment.Lines))
+	}
+	comments.Add(relPath, records)
+
+	return contents, nil
+}
+
+// changedFiles returns the absolute paths of the files in the repository at repoPath changed since
+// --since or staged with --staged
+func changedFiles(repoPath string) (map[string]bool, error) {
+	top, err := git.TopLevel(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	if stagedOnly {
+		files, err = git.StagedFiles(repoPath)
+	} else {
+		files, err = git.ChangedFilesSince(repoPath, sinceRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changed := map[string]bool{}
+	for _, file := range files {
+		changed[filepath.Join(top, filepath.FromSlash(file))] = true
+	}
+	return changed, nil
+}
+
+// changedSymbols returns the keys of the symbols in the file that changed since --since or
+// were staged with --staged. Returns nil if neither flag is set, meaning everything.
+func changedSymbols(path, contents string) (map[string]bool, error) {
+	if sinceRef == "" && !stagedOnly {
+		return nil, nil
+	}
+
+	var ranges [][2]int
+	var err error
+	if stagedOnly {
+		ranges, err = git.StagedChangedLines(path)
+		if err != nil {
+			return nil, err
+		}
+		// the staged lines are numbered in the staged file, which differs from the working
+		// tree if the file has unstaged changes. The symbols are matched by name after.
+		contents, err = git.StagedContents(path)
+	} else {
+		ranges, err = git.ChangedLinesSince(sinceRef, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	syms, err := symbols.Parse(path, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	only := map[string]bool{}
+	for _, sym := range syms {
+		if sym.Overlaps(ranges) {
+			only[sym.Key()] = true
+		}
+	}
+	return only, nil
+}
+
+// stripDocs removes all the comments Otto wrote into the file or the files in the directory
+func stripDocs(path string) {
+	root, err := state.Root(path)
+	if err != nil {
+		log.Errorf("Error: %s", err)
+		os.Exit(1)
+	}
+
+	comments, err := authored.Load(root)
+	if err != nil {
+		log.Errorf("Error loading Otto comments: %s", err)
+		os.Exit(1)
+	}
+
+	relPath, err := state.RelPath(root, path)
+	if err != nil {
+		log.Errorf("Error: %s", err)
+		os.Exit(1)
+	}
+
+	var files []string
+	for file := range comments {
+		if relPath == "." || file == relPath || strings.HasPrefix(file, relPath+"/") {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	if len(files) == 0 {
+		fmt.Println("No Otto comments found.")
+		return
+	}
+
+	w := newWriter()
+	for _, file := range files {
+		fullPath := filepath.Join(root, filepath.FromSlash(file))
+		contents, err := utils.LoadFile(fullPath)
+		if os.IsNotExist(err) {
+			log.Debugf("Forgetting comments for deleted file %s", file)
+			delete(comments, file)
+			continue
+		} else if err != nil {
+			log.Warnf("Error loading file %s: %s", fullPath, err)
+			continue
+		}
+
+		contents, removed, err := comments.Strip(file, contents, nil)
+		if err != nil {
+			log.Warnf("Error stripping comments from %s: %s", file, err)
+			continue
+		}
+
+		err = w.WriteFile(fullPath, contents)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Removed %d comments from %s\n", removed, file)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		log.Errorf("Error: %s", err)
+		os.Exit(1)
+	}
+
+	if !w.Writes() {
+		return
+	}
+
+	err = comments.Save(root)
+	if err != nil {
+		log.Errorf("Error saving Otto comments: %s", err)
+		os.Exit(1)
+	}
+}
+
 func init() {
 	RootCmd.AddCommand(docsCmd)
 
@@ -233,4 +832,12 @@ func init() {
 	docsCmd.Flags().BoolVarP(&overwriteOriginal, "overwrite", "w", false, "Overwrite the original file")
 	docsCmd.Flags().BoolVarP(&ignoreGitignore, "ignore-gitignore", "g", false, "ignore .gitignore file")
 	docsCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	docsCmd.Flags().BoolVar(&stripComments, "strip", false, "Remove all comments written by Otto")
+	docsCmd.Flags().StringVar(&sinceRef, "since", "", "Only document files and declarations changed since the git ref")
+	docsCmd.Flags().BoolVar(&stagedOnly, "staged", false, "Only document files and declarations staged for commit")
+	docsCmd.Flags().IntVarP(&concurrency, "concurrency", "j", 0, "Number of files to document at the same time. Defaults to the configured value or 4")
+	docsCmd.Flags().BoolVar(&overviewMode, "overview", false, "Write ARCHITECTURE.md with a map of the repo, its dependency graph and a summary of each directory")
+	docsCmd.Flags().StringVar(&siteDir, "site", "", "Write a markdown documentation site with one page per directory and an mkdocs.yml to the directory")
+	addWriteFlags(docsCmd)
+	docsCmd.Flags().BoolVar(&noCache, "no-cache", false, "Document files even if they have not changed since they were last documented")
 }
@@ -0,0 +1,214 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/ai"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/drift"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/git"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/state"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	l "github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// docsCheckCmd represents the docs-check command
+var docsCheckCmd = &cobra.Command{
+	Use:   "docs-check [path]",
+	Short: "Check that the documentation still matches the code",
+	Long: `Check that the documentation still matches the code. Doc comments are paired with the declarations
+they document, and the sections of README.md and the markdown files in docs/ are paired with the
+declarations they mention in inline code or headings. The model is asked whether each description
+still matches, and the mismatches are listed with their locations. The command exits with an error if
+any are found, so it can be used in CI.
+
+Pairs that were verified are recorded in .ottodocs/verified.json along with the signature of the
+declaration, and are only checked again when the documentation or the signature changes. Use --force
+to check everything.
+
+Example:
+otto docs-check
+otto docs-check --force
+`,
+	Args: cobra.MaximumNArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			log.SetLevel(l.DebugLevel)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		repoPath := "."
+		if len(args) > 0 {
+			repoPath = args[0]
+		}
+
+		if !git.IsGitRepo(repoPath) {
+			log.Error("Error: not a git repository")
+			os.Exit(1)
+		}
+
+		root, err := state.Root(repoPath)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		verified, err := drift.LoadVerified(root)
+		if err != nil {
+			log.Errorf("Error loading verified documentation: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Getting repo...")
+		repo, err := git.GetRepo(repoPath, ignoreFilePath, ignoreGitignore)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		var pairs []drift.Pair
+		var allSymbols []drift.Symbol
+		for _, file := range repo.Files {
+			relPath, err := state.RelPath(root, filepath.Join(repoPath, file.Path))
+			if err != nil {
+				continue
+			}
+			syms, err := drift.Symbols(relPath, file.Contents)
+			if err != nil {
+				// not a language symbols can be parsed for
+				continue
+			}
+			allSymbols = append(allSymbols, syms...)
+			pairs = append(pairs, drift.CommentPairs(syms, file.Contents)...)
+		}
+
+		index := drift.NewIndex(allSymbols)
+		for _, path := range markdownDocs(repoPath) {
+			contents, err := utils.LoadFile(path)
+			if err != nil {
+				log.Warnf("Error loading %s: %s", path, err)
+				continue
+			}
+			relPath, err := state.RelPath(root, path)
+			if err != nil {
+				continue
+			}
+			pairs = append(pairs, drift.MarkdownPairs(relPath, contents, index)...)
+		}
+
+		var unverified []drift.Pair
+		for _, pair := range pairs {
+			if force || !verified.Unchanged(pair) {
+				unverified = append(unverified, pair)
+			}
+		}
+		log.Debugf("Found %d pairs, %d to check", len(pairs), len(unverified))
+
+		if len(unverified) == 0 {
+			fmt.Printf("All %d documented declarations were already verified.\n", len(pairs))
+			return
+		}
+
+		conf, err := config.Load()
+		if err != nil || conf.APIKey == "" {
+			// if the API key is not set, prompt the user to config
+			log.Error("Please config first.")
+			log.Error("Run `ottodocs config -h` to learn how to config.")
+			os.Exit(1)
+		}
+
+		fmt.Printf("Checking %d documented declarations...\n", len(unverified))
+		results, err := ai.CheckDrift(unverified, conf)
+		if err != nil {
+			log.Errorf("Error checking documentation: %s", err)
+			os.Exit(1)
+		}
+
+		var mismatches []ai.DriftResult
+		for _, result := range results {
+			if result.Matches {
+				verified[result.Pair.ID()] = result.Pair.Hash()
+			} else {
+				delete(verified, result.Pair.ID())
+				mismatches = append(mismatches, result)
+			}
+		}
+
+		// forget the pairs that no longer exist
+		current := map[string]bool{}
+		for _, pair := range pairs {
+			current[pair.ID()] = true
+		}
+		for id := range verified {
+			if !current[id] {
+				delete(verified, id)
+			}
+		}
+
+		err = verified.Save(root)
+		if err != nil {
+			log.Errorf("Error saving verified documentation: %s", err)
+			os.Exit(1)
+		}
+
+		if unchecked := len(unverified) - len(results); unchecked > 0 {
+			log.Warnf("%d declarations were not checked, run the command again to check them", unchecked)
+		}
+
+		if len(mismatches) == 0 {
+			fmt.Println("The documentation matches the code.")
+			return
+		}
+
+		sort.Slice(mismatches, func(i, j int) bool {
+			a, b := mismatches[i].Pair, mismatches[j].Pair
+			if a.DocPath != b.DocPath {
+				return a.DocPath < b.DocPath
+			}
+			return a.DocLine < b.DocLine
+		})
+		for _, mismatch := range mismatches {
+			fmt.Printf("%s: %s (%s): %s\n", mismatch.Pair.Location(), mismatch.Pair.Symbol.Symbol.Key(), mismatch.Pair.Symbol.Path, mismatch.Problem)
+		}
+		log.Errorf("Found %d descriptions that no longer match the code", len(mismatches))
+		os.Exit(1)
+	},
+}
+
+// markdownDocs returns README.md and the markdown files in docs/ of the repository
+func markdownDocs(repoPath string) []string {
+	var paths []string
+	readme := filepath.Join(repoPath, "README.md")
+	if _, err := os.Stat(readme); err == nil {
+		paths = append(paths, readme)
+	}
+
+	filepath.WalkDir(filepath.Join(repoPath, "docs"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".md") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
+
+func init() {
+	RootCmd.AddCommand(docsCheckCmd)
+
+	docsCheckCmd.Flags().BoolVarP(&force, "force", "f", false, "Check all the documentation, even if it was already verified")
+	docsCheckCmd.Flags().StringVarP(&ignoreFilePath, "ignore", "n", "", "path to .gptignore file")
+	docsCheckCmd.Flags().BoolVarP(&ignoreGitignore, "ignore-gitignore", "g", false, "ignore .gitignore file")
+	docsCheckCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/coverage"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/git"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/state"
+	"github.com/chand1012/git2gpt/prompt"
+	l "github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// docsCoverageCmd represents the coverage command
+var docsCoverageCmd = &cobra.Command{
+	Use:   "coverage [path]",
+	Short: "Report how many exported symbols have doc comments",
+	Long: `Report the percentage of exported symbols with doc comments or docstrings for each package
+(directory) of the repository. Go files are parsed with go/ast, other supported languages with
+heuristics. The model is not used, so no config is needed.
+
+The report can be printed as a table (text), as JSON, or as a shields.io endpoint badge (badge).
+Use --threshold to exit with an error when the total coverage is below a percentage, for use in CI.
+
+Example:
+otto coverage
+otto coverage --missing
+otto coverage --format json
+otto coverage --format badge > coverage.json
+otto coverage --threshold 80
+`,
+	Args: cobra.MaximumNArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			log.SetLevel(l.DebugLevel)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		repoPath := "."
+		if len(args) > 0 {
+			repoPath = args[0]
+		}
+
+		if coverageFormat != "text" && coverageFormat != "json" && coverageFormat != "badge" {
+			log.Errorf("Error: unknown format %s, must be text, json or badge", coverageFormat)
+			os.Exit(1)
+		}
+
+		if !git.IsGitRepo(repoPath) {
+			log.Error("Error: not a git repository")
+			os.Exit(1)
+		}
+
+		root, err := state.Root(repoPath)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		log.Debug("Getting repo...")
+		repo, err := git.GetRepo(repoPath, ignoreFilePath, ignoreGitignore)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		var files []prompt.GitFile
+		for _, file := range repo.Files {
+			relPath, err := state.RelPath(root, filepath.Join(repoPath, file.Path))
+			if err != nil {
+				continue
+			}
+			files = append(files, prompt.GitFile{Path: relPath, Contents: file.Contents})
+		}
+
+		report := coverage.Build(files)
+		log.Debugf("Found %d exported symbols in %d packages", report.Total, len(report.Packages))
+
+		var out string
+		switch coverageFormat {
+		case "json":
+			out, err = report.JSON()
+		case "badge":
+			out, err = report.Badge()
+		default:
+			out = report.Text(showMissing)
+		}
+		if err != nil {
+			log.Errorf("Error rendering report: %s", err)
+			os.Exit(1)
+		}
+		fmt.Print(out)
+
+		if coverageThreshold > 0 && report.Percent < coverageThreshold {
+			log.Errorf("Documentation coverage %.1f%% is below the threshold of %.1f%%", report.Percent, coverageThreshold)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(docsCoverageCmd)
+
+	docsCoverageCmd.Flags().StringVar(&coverageFormat, "format", "text", "Output format: text, json or badge")
+	docsCoverageCmd.Flags().Float64VarP(&coverageThreshold, "threshold", "t", 0, "Exit with an error if the total coverage is below this percentage")
+	docsCoverageCmd.Flags().BoolVarP(&showMissing, "missing", "m", false, "List the undocumented symbols in the text report")
+	docsCoverageCmd.Flags().StringVarP(&ignoreFilePath, "ignore", "n", "", "path to .gptignore file")
+	docsCoverageCmd.Flags().BoolVarP(&ignoreGitignore, "ignore-gitignore", "g", false, "ignore .gitignore file")
+	docsCoverageCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+}
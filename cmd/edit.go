@@ -7,14 +7,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
-	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
-	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/git"
-	"github.com/TimeSurgeLabs/ottodocs/pkg/textfile"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	"github.com/chand1012/git2gpt/prompt"
 	"github.com/chand1012/memory"
 	l "github.com/charmbracelet/log"
 	"github.com/sashabaranov/go-openai"
@@ -26,10 +25,27 @@ var editCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "Edit a file using AI",
 	Long: `OttoDocs Edit allows you to use AI to help edit your code files. 
-Provide a file name and a goal, and OttoDocs will return a generated version of the file.
+Provide a file name and a goal, and OttoDocs will edit the file. The model returns search and
+replace edits, so only the code that changes is written and the rest of the file is left alone.
+The changes are shown as a diff and only written after you approve them. Search text that doesn't
+match the file exactly is matched ignoring whitespace, or to the nearest code, and edits that
+still fail are sent back to the model to be corrected. Edited Go files are parsed and formatted
+with gofmt, and other files with the formatters set with otto config --formatter. Syntax errors are
+sent back to the model too, and the edit is rejected if they can't be fixed.
 You can even specify the starting and ending lines for the edit, or choose to append the results to the file:
 
-Example: otto edit main.go --start 1 --end 10 --goal "Refactor the function"`,
+Example: otto edit main.go --start 1 --end 10 --goal "Refactor the function"
+
+To edit several files at once, give glob patterns with --files instead of a file name. ** matches
+any number of directories. The changes to every file are shown as one diff and written together.
+
+Example: otto edit --files 'pkg/**/*.go' --goal "Rename LoadFile to ReadFile and update its callers"
+
+With --verify, the command is run after the edit is written. If it fails, its output is sent back
+to the model and the fixes are written without asking, until it passes or --max-iterations attempts
+have been made, in which case every change is rolled back.
+
+Example: otto edit --files 'pkg/**/*.go' --goal "Handle empty input" --verify "go test ./pkg/..."`,
 	Aliases: []string{"e"},
 	PreRun: func(cmd *cobra.Command, args []string) {
 		if verbose {
@@ -37,7 +53,7 @@ Example: otto edit main.go --start 1 --end 10 --goal "Refactor the function"`,
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
+		if len(args) == 0 && len(editFilePatterns) == 0 {
 			log.Error("Requires a file name as an argument. Example: otto edit main.go")
 			os.Exit(1)
 		}
@@ -48,38 +64,21 @@ Example: otto edit main.go --start 1 --end 10 --goal "Refactor the function"`,
 			os.Exit(1)
 		}
 
-		fileName := args[0]
-
-		// load the file
-		contents, err := utils.LoadFile(fileName)
-		if os.IsNotExist(err) {
-			contents = ""
-		} else if err != nil {
-			log.Errorf("Error loading file: %s", err)
+		if verifyCommand != "" && (dryRun || patchFile != "") {
+			log.Error("Error: --verify needs the edits to be written, it can't be used with --dry-run or --patch")
 			os.Exit(1)
 		}
 
-		if endLine < 0 || startLine < 0 {
-			log.Error("End line must be greater than or equal to start line and both must be greater than or equal to 0")
+		if maxIterations < 1 {
+			log.Error("Error: --max-iterations must be at least 1")
 			os.Exit(1)
 		}
 
-		var editCode string
-		if endLine != 0 {
-			// get the lines to edit
-			lines := strings.Split(contents, "\n")
-			if endLine > len(lines) {
-				log.Error("End line is greater than the number of lines in the file")
-				os.Exit(1)
-			}
-
-			editCode = strings.Join(lines[startLine-1:endLine], "\n")
-		} else {
-			endLine = len(strings.Split(contents, "\n"))
+		if len(editFilePatterns) > 0 && (len(args) > 0 || appendFile || endLine != 0) {
+			log.Error("Error: --files can't be used with a file name, --append or a line range")
+			os.Exit(1)
 		}
 
-		log.Debugf("editing lines %d-%d", startLine, endLine)
-
 		if chatPrompt == "" {
 			chatPrompt, err = utils.Input("Goal: ")
 			if err != nil {
@@ -88,215 +87,139 @@ Example: otto edit main.go --start 1 --end 10 --goal "Refactor the function"`,
 			}
 		}
 
-		var messages []openai.ChatCompletionMessage
-		var newCode string
-
-		var prompt string
-		if editCode != "" {
-			prompt = "EDIT: " + editCode + "\n\nGOAL: " + chatPrompt + "\n\nFILE: " + filePath + "\n\n" + contents + "\n\nBe sure to only output the edited code, do not print the entire file."
-		} else {
-			prompt = "GOAL: " + chatPrompt + "\n\nFILE: " + filePath + "\n\n" + contents
-		}
-
-		client := openai.NewClient(c.APIKey)
-
-		if repoContext {
-			repo, err := git.GetRepo(".", "", false)
-			if err != nil {
-				log.Errorf("Error getting repo: %s", err)
-				os.Exit(1)
-			}
-
-			m, _, err := memory.New(":memory:")
-			if err != nil {
-				log.Errorf("Error creating memory: %s", err)
-				os.Exit(1)
-			}
-
-			for _, file := range repo.Files {
-				if file.Path == fileName {
-					continue
-				}
-				err = m.Add(file.Path, file.Contents)
-				if err != nil {
-					log.Errorf("Error indexing file: %s", err)
-					os.Exit(1)
-				}
-			}
-
-			queryConstructorPrompt := []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: "Convert this goal into a terms to use for a search query. They do not have to be organized, nor a complete sentence. Use no form of punctuation or quotations. Only return the query and nothing else: " + chatPrompt,
-				},
-			}
-
-			utils.PrintColoredText("Otto: ", c.OttoColor)
-			fmt.Println("Ok! Here is the query, taking your input into account.")
-			utils.PrintColoredText("Otto: ", c.OttoColor)
-			stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
-				Model:    "gpt-3.5-turbo",
-				Messages: queryConstructorPrompt,
-			})
-
-			if err != nil {
-				log.Errorf("Error requesting from OpenAI: %s", err)
-				os.Exit(1)
-			}
-
-			query, err := utils.PrintChatCompletionStream(stream)
+		var files []prompt.GitFile
+		goal := chatPrompt
+		if len(editFilePatterns) > 0 {
+			files, err = loadEditFiles(editFilePatterns)
 			if err != nil {
-				log.Errorf("Error printing chat completion stream: %s", err)
+				log.Errorf("Error loading files: %s", err)
 				os.Exit(1)
 			}
-
-			utils.PrintColoredText("Otto: ", c.OttoColor)
-			fmt.Println("Searching repo for files that match the query...")
-
-			resp, err := m.Search(query)
-			if err != nil {
-				log.Errorf("Error searching memory: %s", err)
+			if len(files) == 0 {
+				log.Errorf("Error: no files match %s", strings.Join(editFilePatterns, ", "))
 				os.Exit(1)
 			}
-
-			contextFiles = []string{}
-			for _, file := range resp {
-				contextFiles = append(contextFiles, file.ID)
-			}
-			log.Debugf("context files: %s", contextFiles)
-		}
-		tokens, err := calc.PreciseTokens(prompt)
-		if err != nil {
-			log.Errorf("Error calculating tokens: %s", err)
-			os.Exit(1)
+		} else {
+			files, goal = singleEditFile(args[0], goal)
 		}
 
-		maxTokens := calc.GetMaxTokens(c.Model)
-		if len(contextFiles) > 0 {
-			var contextContent string
-			for _, contextFile := range contextFiles {
-				contextContent, err = utils.LoadFile(contextFile)
-				if err != nil {
-					log.Errorf("Error loading context file: %s", err)
-					continue
-				}
-				contentTokens, err := calc.PreciseTokens(contextContent)
-				if err != nil {
-					log.Errorf("Error loading context file: %s", err)
-					continue
-				}
-				if contentTokens+tokens > maxTokens {
-					break
-				}
-				prompt += "\n\nCONTEXT: " + contextFile + "\n\n" + contextContent
-				tokens += contentTokens
+		if repoContext {
+			var skip []string
+			for _, file := range files {
+				skip = append(skip, file.Path)
 			}
+			contextFiles = searchRepoContext(c, skip)
 		}
 
-		log.Debugf("prompt: %s", prompt)
-
-		messages = []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: constants.EDIT_CODE_PROMPT,
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		}
-
-		for {
-
-			stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
-				Model:    c.Model,
-				Messages: messages,
-			})
-
-			if err != nil {
-				log.Errorf("Error requesting from OpenAI: %s", err)
-				os.Exit(1)
-			}
-
-			// print the response
-			utils.PrintColoredTextLn("New Code:", c.OttoColor)
-			newCode, err = utils.PrintChatCompletionStream(stream)
-			if err != nil {
-				log.Errorf("Error printing chat completion stream: %s", err)
-				os.Exit(1)
-			}
-
-			confirmMsg := "Would you like to write the file with the new code? (y/N). Type your input to keep editing: "
-			if appendFile {
-				confirmMsg = "Would you like to append the new code to the file? (y/N). Type your input to keep editing: "
-			}
+		runEdit(c, files, nil, goal)
+	},
+}
 
-			if !force {
-				confirm, err := utils.Input(confirmMsg)
-				if err != nil {
-					log.Errorf("Error getting input: %s", err)
-					os.Exit(1)
-				}
-
-				confirm = strings.ToLower(confirm)
-				if confirm == "n" || confirm == "no" {
-					os.Exit(0)
-				} else if confirm == "y" || confirm == "yes" {
-					break
-				} else {
-					codeTokens, err := calc.PreciseTokens(newCode)
-					if err != nil {
-						log.Errorf("Error calculating tokens: %s", err)
-						os.Exit(1)
-					}
-
-					maxTokens := calc.GetMaxTokens(c.Model) - codeTokens
-
-					var newMessages []openai.ChatCompletionMessage
-					newMessages = []openai.ChatCompletionMessage{
-						{
-							Role:    openai.ChatMessageRoleUser,
-							Content: "Use the following input to edit the code: " + confirm + "\n\nMake sure to only output the code, do not print anything else.",
-						},
-						{
-							Role:    openai.ChatMessageRoleAssistant,
-							Content: newCode,
-						},
-					}
-					utils.ReverseSlice(messages)
-					for _, message := range messages {
-						if calc.PreciseTokensFromMessages(newMessages, c.Model) < maxTokens {
-							newMessages = append(newMessages, message)
-						}
-					}
-					utils.ReverseSlice(newMessages)
-					messages = newMessages
-					utils.PrintColoredText("Otto: ", c.OttoColor)
-					fmt.Println("Ok! Here is the new code, taking your input into account.")
-				}
-			}
+// singleEditFile loads the file to edit and adds the line range or appending to the goal
+func singleEditFile(fileName, goal string) ([]prompt.GitFile, string) {
+	fileName = filepath.ToSlash(filepath.Clean(fileName))
+	contents, err := utils.LoadFile(fileName)
+	if os.IsNotExist(err) {
+		if endLine != 0 {
+			log.Error("Error: a line range can't be given for a new file")
+			os.Exit(1)
 		}
+		return nil, goal + "\n\nCreate the file " + fileName + "."
+	} else if err != nil {
+		log.Errorf("Error loading file: %s", err)
+		os.Exit(1)
+	}
+
+	if endLine < 0 || startLine < 0 {
+		log.Error("End line must be greater than or equal to start line and both must be greater than or equal to 0")
+		os.Exit(1)
+	}
+
+	files := []prompt.GitFile{{Path: fileName, Contents: contents}}
+	if appendFile {
+		return files, goal + "\n\nOnly add code to the end of " + fileName + ", with an empty search text."
+	}
+	if endLine == 0 {
+		return files, goal
+	}
+
+	lines := strings.Split(contents, "\n")
+	if endLine > len(lines) || startLine > endLine {
+		log.Error("End line is greater than the number of lines in the file")
+		os.Exit(1)
+	}
+	log.Debugf("editing lines %d-%d", startLine, endLine)
+	return files, fmt.Sprintf("%s\n\nOnly change lines %d to %d of %s:\n\nEDIT: %s", goal, startLine, endLine, fileName, strings.Join(lines[startLine-1:endLine], "\n"))
+}
 
-		var finalCode string
-		if appendFile {
-			// add the new code to the end of the file
-			finalCode = contents + "\n" + newCode + "\n"
-		} else {
-			// write the new code to the file
-			finalCode, err = textfile.ReplaceLines(contents, startLine, endLine, newCode)
-			if err != nil {
-				log.Errorf("Error replacing lines: %s", err)
-				os.Exit(1)
-			}
+// searchRepoContext finds the files of the repo that are relevant to the goal, other than the skipped ones
+func searchRepoContext(c *config.Config, skip []string) []string {
+	repo, err := git.GetRepo(".", "", false)
+	if err != nil {
+		log.Errorf("Error getting repo: %s", err)
+		os.Exit(1)
+	}
+
+	m, _, err := memory.New(":memory:")
+	if err != nil {
+		log.Errorf("Error creating memory: %s", err)
+		os.Exit(1)
+	}
+
+	for _, file := range repo.Files {
+		if utils.Contains(skip, filepath.ToSlash(file.Path)) {
+			continue
 		}
-
-		err = utils.WriteFile(fileName, finalCode)
+		err = m.Add(file.Path, file.Contents)
 		if err != nil {
-			log.Errorf("Error writing file: %s", err)
+			log.Errorf("Error indexing file: %s", err)
 			os.Exit(1)
 		}
-		fmt.Println("File written successfully!")
-	},
+	}
+
+	queryConstructorPrompt := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: "Convert this goal into a terms to use for a search query. They do not have to be organized, nor a complete sentence. Use no form of punctuation or quotations. Only return the query and nothing else: " + chatPrompt,
+		},
+	}
+
+	client := openai.NewClient(c.APIKey)
+
+	utils.PrintColoredText("Otto: ", c.OttoColor)
+	fmt.Println("Ok! Here is the query, taking your input into account.")
+	utils.PrintColoredText("Otto: ", c.OttoColor)
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    "gpt-3.5-turbo",
+		Messages: queryConstructorPrompt,
+	})
+
+	if err != nil {
+		log.Errorf("Error requesting from OpenAI: %s", err)
+		os.Exit(1)
+	}
+
+	query, err := utils.PrintChatCompletionStream(stream)
+	if err != nil {
+		log.Errorf("Error printing chat completion stream: %s", err)
+		os.Exit(1)
+	}
+
+	utils.PrintColoredText("Otto: ", c.OttoColor)
+	fmt.Println("Searching repo for files that match the query...")
+
+	resp, err := m.Search(query)
+	if err != nil {
+		log.Errorf("Error searching memory: %s", err)
+		os.Exit(1)
+	}
+
+	var found []string
+	for _, file := range resp {
+		found = append(found, file.ID)
+	}
+	log.Debugf("context files: %s", found)
+	return found
 }
 
 func init() {
@@ -310,4 +233,8 @@ func init() {
 	editCmd.Flags().IntVarP(&endLine, "end", "e", 0, "End line")
 	editCmd.Flags().StringVarP(&chatPrompt, "goal", "g", "", "Goal of the edit")
 	editCmd.Flags().StringSliceVarP(&contextFiles, "context", "c", []string{}, "Context files")
+	editCmd.Flags().StringSliceVar(&editFilePatterns, "files", []string{}, "Glob patterns of files to edit together, instead of a file name")
+	editCmd.Flags().StringVar(&verifyCommand, "verify", "", "Command to run after the edit, failures are sent back to the model until it passes")
+	editCmd.Flags().IntVar(&maxIterations, "max-iterations", 3, "Most attempts to make the --verify command pass before rolling back")
+	addWriteFlags(editCmd)
 }
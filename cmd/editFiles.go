@@ -0,0 +1,196 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chand1012/git2gpt/prompt"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/ai"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/edits"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/postedit"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/writer"
+)
+
+// loadEditFiles loads the files matching the patterns, with slash separated paths. Binary
+// files are left out.
+func loadEditFiles(patterns []string) ([]prompt.GitFile, error) {
+	paths, err := utils.GlobAll(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []prompt.GitFile
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		contents, err := utils.LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.IndexByte([]byte(contents), 0) >= 0 {
+			log.Debugf("Skipping binary file %s", path)
+			continue
+		}
+		files = append(files, prompt.GitFile{Path: filepath.ToSlash(filepath.Clean(path)), Contents: contents})
+	}
+	return files, nil
+}
+
+// runEdit edits the files towards the goal. The model returns search and replace edits, which
+// are shown as one diff and written together once approved. The context is sent along with the
+// context files, but can't be edited.
+func runEdit(c *config.Config, files, context []prompt.GitFile, goal string) {
+	log.Debugf("Editing %d files", len(files))
+
+	if verifyCommand != "" {
+		err := checkVerifyCommand(verifyCommand)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+	}
+
+	// leave a quarter of the context for the edits
+	budget := calc.GetMaxTokens(c.Model) * 3 / 4
+	tokens := calc.EstimateTokens(constants.EDIT_FILES_PROMPT + goal)
+	contents := map[string]string{}
+	for _, file := range files {
+		tokens += calc.EstimateTokens(file.Contents)
+		contents[file.Path] = file.Contents
+	}
+	if tokens > budget {
+		log.Errorf("Error: the files are too large for %s, edit fewer files at a time", c.Model)
+		os.Exit(1)
+	}
+
+	for _, file := range context {
+		tokens += calc.EstimateTokens(file.Contents)
+	}
+	if tokens > budget {
+		log.Errorf("Error: the files are too large for %s", c.Model)
+		os.Exit(1)
+	}
+
+	for _, contextFile := range contextFiles {
+		contextContent, err := utils.LoadFile(contextFile)
+		if err != nil {
+			log.Errorf("Error loading context file: %s", err)
+			continue
+		}
+		contentTokens := calc.EstimateTokens(contextContent)
+		if tokens+contentTokens > budget {
+			log.Warnf("Context file %s doesn't fit in the context of %s, skipping it", contextFile, c.Model)
+			continue
+		}
+		context = append(context, prompt.GitFile{Path: filepath.ToSlash(contextFile), Contents: contextContent})
+		tokens += contentTokens
+	}
+
+	exists := func(path string) bool {
+		_, err := os.Stat(filepath.FromSlash(path))
+		return err == nil
+	}
+
+	w := newWriter()
+	editor := ai.NewFileEditor(goal, files, context, c)
+	checker := &postedit.Checker{Formatters: c.Formatters}
+	editor.Check = checker.Check
+	label := fmt.Sprintf("%d files", len(files))
+	if len(files) < 2 {
+		label = "the file"
+	}
+
+	var changes *edits.ChangeSet
+	for {
+		fmt.Printf("Editing %s...\n", label)
+		var explanation string
+		var err error
+		changes, explanation, err = editor.Changes(contents, exists)
+		if err != nil {
+			log.Errorf("Error editing files: %s", err)
+			os.Exit(1)
+		}
+		if len(changes.Updated) == 0 {
+			utils.PrintColoredText("Otto: ", c.OttoColor)
+			fmt.Println(explanation)
+			log.Warn("The edits don't change any files")
+			os.Exit(1)
+		}
+
+		// a dry run or patch file shows the diff itself
+		if !w.Writes() {
+			break
+		}
+
+		fmt.Print(changes.Diff())
+		utils.PrintColoredText("Otto: ", c.OttoColor)
+		fmt.Println(explanation)
+		if force {
+			break
+		}
+
+		confirm, err := utils.Input(fmt.Sprintf("Would you like to apply the changes to %d files? (y/N). Type your input to keep editing: ", len(changes.Updated)))
+		if err != nil {
+			log.Errorf("Error getting input: %s", err)
+			os.Exit(1)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(confirm)) {
+		case "", "n", "no":
+			os.Exit(0)
+		case "y", "yes":
+		default:
+			editor.Refine(confirm)
+			utils.PrintColoredText("Otto: ", c.OttoColor)
+			fmt.Println("Ok! Here are the new changes, taking your input into account.")
+			continue
+		}
+		break
+	}
+
+	err := writeChanges(w, changes)
+	if err != nil {
+		log.Errorf("Error writing files: %s", err)
+		os.Exit(1)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		log.Errorf("Error writing diff: %s", err)
+		os.Exit(1)
+	}
+
+	if !w.Writes() {
+		if patchFile != "" {
+			fmt.Printf("Patch written to %s\n", patchFile)
+		}
+		return
+	}
+	fmt.Printf("%d files written successfully!\n", len(changes.Updated))
+
+	if verifyCommand != "" {
+		verifyEdit(c, w, editor, contents, changes, exists)
+	}
+}
+
+// writeChanges writes the changed files together
+func writeChanges(w *writer.Writer, changes *edits.ChangeSet) error {
+	updated := map[string]string{}
+	for _, path := range changes.Paths() {
+		updated[filepath.FromSlash(path)] = changes.Updated[path]
+	}
+	return w.WriteFiles(updated)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/ai"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/edits"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/writer"
+)
+
+// the most of the output of a failed verification sent back to the model
+const (
+	maxVerifyLines = 80
+	maxVerifyBytes = 8000
+)
+
+// the shell builtins a verification command can start with, which aren't found on the PATH
+var shellBuiltins = []string{".", ":", "[", "cd", "command", "eval", "exec", "export", "set", "source", "test", "true", "false", "echo", "if", "for", "while", "until", "case", "!", "{", "("}
+
+// checkVerifyCommand checks that the program the verification command runs exists, so a typo isn't
+// mistaken for a failure of the edits
+func checkVerifyCommand(command string) error {
+	for _, word := range strings.Fields(command) {
+		// skip environment variables set for the command
+		if name, _, ok := strings.Cut(word, "="); ok && name != "" && !strings.ContainsAny(name, "/\\$") {
+			continue
+		}
+		if utils.Contains(shellBuiltins, word) || strings.ContainsAny(word, "$`'\"(){}") {
+			return nil
+		}
+		if _, err := exec.LookPath(word); err != nil {
+			return fmt.Errorf("the verification command %s was not found", word)
+		}
+		return nil
+	}
+	return errors.New("the verification command is empty")
+}
+
+// runVerify runs the command in a shell and returns its output and whether it passed
+func runVerify(command string) (string, bool, error) {
+	var cmd *exec.Cmd
+	notFound := 127
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+		notFound = 9009
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// the shell couldn't find the command, which the model can't fix
+		if exitErr.ExitCode() == notFound {
+			return string(out), false, fmt.Errorf("command not found: %s", strings.TrimSpace(string(out)))
+		}
+		return string(out), false, nil
+	} else if err != nil {
+		return string(out), false, err
+	}
+	return string(out), true, nil
+}
+
+// trimOutput keeps the end of the output, where test runners and compilers report failures
+func trimOutput(output string) string {
+	output = strings.TrimSpace(output)
+	lines := strings.Split(output, "\n")
+	trimmed := len(lines) > maxVerifyLines
+	if trimmed {
+		lines = lines[len(lines)-maxVerifyLines:]
+	}
+	output = strings.Join(lines, "\n")
+	if len(output) > maxVerifyBytes {
+		output = output[len(output)-maxVerifyBytes:]
+		trimmed = true
+	}
+	if trimmed {
+		output = "...\n" + output
+	}
+	return output
+}
+
+// verifyEdit runs the verification command after the edit is written. While it fails, the output
+// is sent to the model for edits that fix it, which are written without asking, up to --max-iterations
+// attempts in total. If it never passes every file is restored.
+func verifyEdit(c *config.Config, w *writer.Writer, editor *ai.FileEditor, files map[string]string, changes *edits.ChangeSet, exists func(string) bool) {
+	// the contents of the files before the first edit, nil for files that were created
+	originals := map[string]*string{}
+	current := map[string]string{}
+	for path, contents := range files {
+		current[path] = contents
+	}
+	track := func(changes *edits.ChangeSet) {
+		for _, path := range changes.Paths() {
+			if _, ok := originals[path]; !ok {
+				if changes.Created(path) {
+					originals[path] = nil
+				} else {
+					original := changes.Original[path]
+					originals[path] = &original
+				}
+			}
+			current[path] = changes.Updated[path]
+		}
+	}
+	track(changes)
+
+	rollback := func() {
+		restore := map[string]string{}
+		for path, original := range originals {
+			if original == nil {
+				err := os.Remove(filepath.FromSlash(path))
+				if err != nil && !os.IsNotExist(err) {
+					log.Errorf("Error removing %s: %s", path, err)
+				}
+				continue
+			}
+			restore[filepath.FromSlash(path)] = *original
+		}
+		err := w.WriteFiles(restore)
+		if err != nil {
+			log.Errorf("Error restoring files: %s", err)
+			os.Exit(1)
+		}
+		fmt.Println("The changes were rolled back.")
+	}
+
+	// the output of the last run, which is still current if the edits after it were discarded
+	var output string
+	discarded := false
+	for attempt := 1; ; attempt++ {
+		if !discarded {
+			fmt.Printf("Running %s...\n", verifyCommand)
+			var passed bool
+			var err error
+			output, passed, err = runVerify(verifyCommand)
+			if err != nil {
+				log.Errorf("Error running %s: %s", verifyCommand, err)
+				rollback()
+				os.Exit(1)
+			}
+			if passed {
+				utils.PrintColoredText("Otto: ", c.OttoColor)
+				if attempt == 1 {
+					fmt.Println("The verification passed.")
+				} else {
+					fmt.Printf("The verification passed after %d attempts.\n", attempt)
+				}
+				return
+			}
+			log.Debugf("verification output:\n%s", output)
+		}
+		discarded = false
+
+		if attempt >= maxIterations {
+			fmt.Println(trimOutput(output))
+			log.Errorf("The verification still fails after %d attempts", attempt)
+			rollback()
+			os.Exit(1)
+		}
+
+		utils.PrintColoredText("Otto: ", c.OttoColor)
+		fmt.Printf("The verification failed, trying again (attempt %d of %d)...\n", attempt+1, maxIterations)
+		editor.Fix(verifyCommand, trimOutput(output))
+
+		changes, _, err := editor.Changes(current, exists)
+		var applyErr *edits.ApplyError
+		var checkErr *ai.CheckError
+		if errors.As(err, &applyErr) || errors.As(err, &checkErr) {
+			// counts as a failed attempt, the files are left as they were
+			log.Warn(err)
+			editor.Discard(err.Error())
+			discarded = true
+			continue
+		}
+		if err != nil {
+			log.Errorf("Error editing files: %s", err)
+			rollback()
+			os.Exit(1)
+		}
+		if len(changes.Updated) == 0 {
+			log.Warn("The model made no changes")
+			discarded = true
+			continue
+		}
+		fmt.Print(changes.Diff())
+
+		err = writeChanges(w, changes)
+		if err != nil {
+			log.Errorf("Error writing files: %s", err)
+			rollback()
+			os.Exit(1)
+		}
+		track(changes)
+	}
+}
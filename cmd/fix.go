@@ -0,0 +1,102 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	l "github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/ai"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/shell"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+)
+
+// fixCmd represents the fix command
+var fixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Explain why the last command failed and suggest a fix",
+	Long: `Explain why the last command failed and suggest a corrected command. The command, its exit code and
+the end of its error output are recorded by the shell integration, set it up first with otto shell init.
+The suggested command is only printed, never run.
+
+Example:
+otto fix
+otto fix -q "I'm trying to push to a new branch"
+`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			log.SetLevel(l.DebugLevel)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.Load()
+		if err != nil || conf.APIKey == "" {
+			// if the API key is not set, prompt the user to config
+			log.Error("Please config first.")
+			log.Error("Run `ottodocs config -h` to learn how to config.")
+			os.Exit(1)
+		}
+
+		dir, err := shell.CaptureDir()
+		if err != nil {
+			log.Errorf("Error getting home directory: %s", err)
+			os.Exit(1)
+		}
+
+		last, err := shell.LoadLastCommand(dir)
+		if errors.Is(err, shell.ErrNotRecorded) {
+			log.Error("No command has been recorded yet.")
+			log.Error("Run `otto shell init -h` to learn how to set up the shell integration.")
+			os.Exit(1)
+		} else if err != nil {
+			log.Errorf("Error loading the last command: %s", err)
+			os.Exit(1)
+		}
+		log.Debugf("Last command recorded at %s", last.Time.Format("2006-01-02 15:04:05"))
+
+		if last.ExitCode == 0 {
+			fmt.Printf("The last command succeeded, there is nothing to fix: %s\n", last.Command)
+			return
+		}
+
+		// the history is only context, so it's fine if there is none
+		history, err := shell.GetHistory(20)
+		if err != nil {
+			log.Debugf("Error getting shell history, continuing without it: %s", err)
+		}
+		var recent []string
+		for _, line := range history {
+			if line == "otto" || strings.HasPrefix(line, "otto ") {
+				continue
+			}
+			recent = append(recent, line)
+		}
+
+		fmt.Printf("Fixing `%s` (exit code %d)...\n", last.Command, last.ExitCode)
+		stream, err := ai.FixCommand(last, recent, chatPrompt, conf)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+
+		_, err = utils.PrintChatCompletionStream(stream)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(fixCmd)
+
+	fixCmd.Flags().StringVarP(&chatPrompt, "question", "q", "", "More information about what you were trying to do")
+	fixCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+}
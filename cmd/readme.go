@@ -0,0 +1,220 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/ai"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/git"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/readme"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	l "github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// readmeCmd represents the readme command
+var readmeCmd = &cobra.Command{
+	Use:   "readme [path]",
+	Short: "Create or update the README of a repository",
+	Long: `Create or update README.md from the contents of the repository. Otto writes the install, usage,
+configuration and contributing sections, each wrapped in markers:
+
+<!-- otto:start usage sources=1a2b3c4d5e6f -->
+## Usage
+...
+<!-- otto:end usage -->
+
+Only the text between the markers is ever rewritten, everything else in the README is left alone. Move
+the sections around or delete them as you like. The marker records a hash of the files the section was
+written from, so only the sections whose code changed are rewritten. Use --check to list the stale
+sections without changing anything, for example in CI.
+
+If there is no README, one is created with all the sections. Use --sections to choose which to write
+or to add new ones to an existing README.
+
+Example:
+otto readme
+otto readme --sections usage,configuration
+otto readme --check
+`,
+	Args: cobra.MaximumNArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			log.SetLevel(l.DebugLevel)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		repoPath := "."
+		if len(args) > 0 {
+			repoPath = args[0]
+		}
+
+		for _, name := range readmeSections {
+			if _, ok := readme.GetKind(name); !ok {
+				log.Errorf("Error: unknown section %s. Valid sections are: %s", name, strings.Join(readme.Names(), ", "))
+				os.Exit(1)
+			}
+		}
+
+		if !git.IsGitRepo(repoPath) {
+			log.Error("Error: not a git repository")
+			os.Exit(1)
+		}
+
+		readmePath := filepath.Join(repoPath, "README.md")
+		contents, err := utils.LoadFile(readmePath)
+		isNew := os.IsNotExist(err)
+		if err != nil && !isNew {
+			log.Errorf("Error loading README: %s", err)
+			os.Exit(1)
+		}
+
+		sections, err := readme.Parse(contents)
+		if err != nil {
+			log.Errorf("Error parsing README: %s", err)
+			os.Exit(1)
+		}
+
+		existing := map[string]readme.Section{}
+		for _, section := range sections {
+			existing[section.Name] = section
+		}
+
+		// the sections to check or write
+		names := readmeSections
+		if len(names) == 0 && isNew {
+			names = readme.Names()
+		} else if len(names) == 0 {
+			for _, section := range sections {
+				if _, ok := readme.GetKind(section.Name); ok {
+					names = append(names, section.Name)
+				} else {
+					log.Warnf("Skipping unknown section %s", section.Name)
+				}
+			}
+		}
+
+		if len(names) == 0 {
+			fmt.Println("No Otto sections found in the README. Use --sections to add some.")
+			return
+		}
+
+		log.Debug("Getting repo...")
+		repo, err := git.GetRepo(repoPath, ignoreFilePath, ignoreGitignore)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+		files := addRootFiles(repoPath, repo.Files, readme.RootFiles...)
+
+		sources := map[string]map[string]string{}
+		hashes := map[string]string{}
+		var stale []string
+		for _, name := range names {
+			kind, _ := readme.GetKind(name)
+			sources[name] = kind.Sources(files)
+			hashes[name] = readme.HashSources(sources[name])
+
+			section, ok := existing[name]
+			if !ok || section.Sources != hashes[name] {
+				stale = append(stale, name)
+			}
+		}
+
+		if checkOnly {
+			for _, name := range names {
+				switch {
+				case existing[name].Name == "":
+					fmt.Printf("%s: missing\n", name)
+				case utils.Contains(stale, name):
+					fmt.Printf("%s: stale\n", name)
+				default:
+					fmt.Printf("%s: up to date\n", name)
+				}
+			}
+			if len(stale) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+
+		if force {
+			stale = names
+		}
+		if len(stale) == 0 {
+			fmt.Println("The README is up to date.")
+			return
+		}
+
+		conf, err := config.Load()
+		if err != nil || conf.APIKey == "" {
+			// if the API key is not set, prompt the user to config
+			log.Error("Please config first.")
+			log.Error("Run `ottodocs config -h` to learn how to config.")
+			os.Exit(1)
+		}
+
+		root, err := filepath.Abs(repoPath)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+		name := filepath.Base(root)
+
+		bodies := map[string]string{}
+		for _, section := range stale {
+			fmt.Printf("Writing the %s section...\n", section)
+			kind, _ := readme.GetKind(section)
+			body, err := ai.ReadmeSection(name, kind, sources[section], existing[section].Body, conf)
+			if err != nil {
+				log.Errorf("Error writing the %s section: %s", section, err)
+				os.Exit(1)
+			}
+			bodies[section] = body
+		}
+
+		if isNew {
+			contents = "# " + name + "\n"
+		}
+		contents, err = readme.Replace(contents, names, bodies, hashes)
+		if err != nil {
+			log.Errorf("Error updating README: %s", err)
+			os.Exit(1)
+		}
+
+		w := newWriter()
+		err = w.WriteFile(readmePath, contents)
+		if err != nil {
+			log.Errorf("Error writing README: %s", err)
+			os.Exit(1)
+		}
+
+		err = w.Flush()
+		if err != nil {
+			log.Errorf("Error writing README: %s", err)
+			os.Exit(1)
+		}
+
+		if w.Writes() {
+			fmt.Printf("Updated %s in %s\n", strings.Join(stale, ", "), readmePath)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(readmeCmd)
+
+	readmeCmd.Flags().StringSliceVarP(&readmeSections, "sections", "s", []string{}, "Sections to write: "+strings.Join(readme.Names(), ", "))
+	readmeCmd.Flags().BoolVarP(&checkOnly, "check", "c", false, "List the stale sections and exit with an error if there are any")
+	readmeCmd.Flags().BoolVarP(&force, "force", "f", false, "Rewrite the sections even if they are up to date")
+	readmeCmd.Flags().StringVarP(&ignoreFilePath, "ignore", "n", "", "path to .gptignore file")
+	readmeCmd.Flags().BoolVarP(&ignoreGitignore, "ignore-gitignore", "g", false, "ignore .gitignore file")
+	readmeCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	addWriteFlags(readmeCmd)
+}
@@ -6,6 +6,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/TimeSurgeLabs/ottodocs/pkg/ai"
@@ -23,7 +24,12 @@ var releaseCmd = &cobra.Command{
 	Use:   "release",
 	Short: "Generate GitHub release notes from git commit logs",
 	Long: `This command generates GitHub release notes from git commit logs.
-It will create a new release given a tag and post it to GitHub as a draft.`,
+It will create a new release given a tag and post it to GitHub as a draft.
+Use --publish to publish the release immediately. Tags with a semver pre-release
+suffix (e.g. v1.2.0-rc.1) are marked as prereleases automatically.
+
+Example:
+otto release -p v1.1.0 -t v1.2.0 --publish --assets "dist/*.zip" --assets "dist/*.tar.gz"`,
 	Aliases: []string{"r"},
 	PreRun: func(cmd *cobra.Command, args []string) {
 		if verbose {
@@ -100,13 +106,79 @@ It will create a new release given a tag and post it to GitHub as a draft.`,
 			os.Exit(1)
 		}
 
-		err = gh.CreateDraftRelease(owner, repo, currentTag, releaseNotes, currentTag, c)
+		release := &gh.Release{
+			TagName:    currentTag,
+			Name:       currentTag,
+			Body:       releaseNotes,
+			Draft:      !publishRelease,
+			Prerelease: gh.IsPrerelease(currentTag),
+		}
+
+		assets, err := utils.GlobAll(releaseAssets)
 		if err != nil {
-			log.Errorf("Error creating release: %s", err)
+			log.Errorf("Error matching asset patterns: %s", err)
 			os.Exit(1)
 		}
 
-		fmt.Println("Release created successfully!")
+		if len(releaseAssets) > 0 && len(assets) == 0 {
+			log.Warn("No files matched the asset patterns")
+		}
+
+		var existing *gh.ReleaseResponse
+		if updateRelease {
+			log.Debugf("Looking for existing release for tag %s...", currentTag)
+			existing, err = gh.GetReleaseByTag(owner, repo, currentTag, c)
+			if err != nil && err != gh.ErrReleaseNotFound {
+				log.Errorf("Error getting release: %s", err)
+				os.Exit(1)
+			}
+		}
+
+		var resp *gh.ReleaseResponse
+		if existing != nil {
+			log.Debugf("Updating release %d...", existing.ID)
+			// only --publish changes whether an existing release is a draft
+			release.Draft = existing.Draft && !publishRelease
+			resp, err = gh.UpdateRelease(owner, repo, existing.ID, release, c)
+			if err != nil {
+				log.Errorf("Error updating release: %s", err)
+				os.Exit(1)
+			}
+		} else {
+			resp, err = gh.CreateRelease(owner, repo, release, c)
+			if err != nil {
+				log.Errorf("Error creating release: %s", err)
+				os.Exit(1)
+			}
+		}
+
+		for _, asset := range assets {
+			// replace assets with the same name when updating a release
+			for _, existingAsset := range resp.Assets {
+				if existingAsset.Name == filepath.Base(asset) {
+					log.Debugf("Deleting existing asset %s...", existingAsset.Name)
+					err = gh.DeleteReleaseAsset(owner, repo, existingAsset.ID, c)
+					if err != nil {
+						log.Errorf("Error deleting asset %s: %s", existingAsset.Name, err)
+						os.Exit(1)
+					}
+				}
+			}
+
+			fmt.Printf("Uploading %s...\n", asset)
+			err = gh.UploadReleaseAsset(resp.UploadURL, asset, c)
+			if err != nil {
+				log.Errorf("Error uploading asset: %s", err)
+				os.Exit(1)
+			}
+		}
+
+		if existing != nil {
+			fmt.Println("Release updated successfully!")
+		} else {
+			fmt.Println("Release created successfully!")
+		}
+		fmt.Println(resp.HTMLURL)
 	},
 }
 
@@ -117,4 +189,7 @@ func init() {
 	releaseCmd.Flags().BoolVarP(&force, "force", "f", false, "Do not prompt for confirmation")
 	releaseCmd.Flags().StringVarP(&previousTag, "prev-tag", "p", "", "Previous tag")
 	releaseCmd.Flags().StringVarP(&currentTag, "tag", "t", "", "Current tag")
+	releaseCmd.Flags().BoolVar(&publishRelease, "publish", false, "Publish the release instead of creating a draft")
+	releaseCmd.Flags().BoolVarP(&updateRelease, "update", "u", false, "Update the existing release for the tag if there is one")
+	releaseCmd.Flags().StringSliceVarP(&releaseAssets, "assets", "a", []string{}, "Glob patterns of files to upload as release assets")
 }
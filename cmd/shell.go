@@ -0,0 +1,24 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// shellCmd represents the shell command
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Set up the shell integration",
+	Long: `Set up the shell integration, which records the last command you ran, its exit code and the end
+of its error output so otto fix can explain failures.
+
+Example:
+eval "$(otto shell init zsh)"
+`,
+}
+
+func init() {
+	RootCmd.AddCommand(shellCmd)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/shell"
+)
+
+// shellInitCmd represents the shell init command
+var shellInitCmd = &cobra.Command{
+	Use:   "init <bash|zsh|fish>",
+	Short: "Print the shell integration script",
+	Long: `Print the script that sets up the shell integration. After each command it records the command, its
+exit code and the end of its error output in ~/.ottodocs/shell, which otto fix uses to explain the failure.
+Commands that run otto aren't recorded.
+
+Error output is copied with tee, so it still reaches the terminal but programs no longer see a terminal on
+stderr, which turns off colors in some of them. Set OTTO_CAPTURE_STDERR=0 before loading the script to only
+record the command and exit code. Bash 4.4 or newer is needed. Fish can't capture error output, so only the
+command and exit code are recorded.
+
+Add it to the config of your shell:
+bash (~/.bashrc):            eval "$(otto shell init bash)"
+zsh (~/.zshrc):              eval "$(otto shell init zsh)"
+fish (~/.config/fish/config.fish): otto shell init fish | source
+`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: shell.Shells,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := shell.CaptureDir()
+		if err != nil {
+			log.Errorf("Error getting home directory: %s", err)
+			os.Exit(1)
+		}
+
+		script, err := shell.InitScript(args[0], dir, maxStderr)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Print(script)
+	},
+}
+
+func init() {
+	shellCmd.AddCommand(shellInitCmd)
+
+	shellInitCmd.Flags().IntVar(&maxStderr, "max-stderr", shell.DefaultMaxStderr, "Most bytes of error output to keep")
+}
@@ -0,0 +1,23 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// testCmd represents the test command
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Work with the tests of a repository",
+	Long: `Work with the tests of a repository.
+
+Example:
+otto test gen pkg/utils/strings.go
+`,
+}
+
+func init() {
+	RootCmd.AddCommand(testCmd)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chand1012/git2gpt/prompt"
+	l "github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/git"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/routes"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/symbols"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/testgen"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+)
+
+// testGenCmd represents the test gen command
+var testGenCmd = &cobra.Command{
+	Use:   "gen <file>",
+	Short: "Generate unit tests for a file or function",
+	Long: `Generate unit tests for a file, or only one function of it with --func. The tests are written
+from the file and the declarations it refers to elsewhere in the repository.
+
+Go tests are table-driven and written to the _test.go file in the same package. Python tests use
+pytest and are written to test_<file>.py, JavaScript and TypeScript tests use Jest and are written to
+<file>.test.<ext>, and Rust tests are added to a tests module at the end of the file. Tests that
+already exist are kept.
+
+The tests are shown as a diff and written once approved, then run. If they fail to compile or pass,
+the output is sent back to the model to fix them, up to --max-iterations attempts. If they never pass
+the tests are rolled back. Use --run to change the command that runs them.
+
+Example:
+otto test gen pkg/utils/strings.go
+otto test gen pkg/calc/tokens.go --func GetMaxTokens
+otto test gen src/parser.ts --run "npx vitest run src/parser.test.ts"
+`,
+	Args: cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			log.SetLevel(l.DebugLevel)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load()
+		if err != nil || c.APIKey == "" {
+			log.Error("Please config first.")
+			log.Error("Run `ottodocs config -h` to learn how to config.")
+			os.Exit(1)
+		}
+
+		if maxIterations < 1 {
+			log.Error("Error: --max-iterations must be at least 1")
+			os.Exit(1)
+		}
+
+		filePath := filepath.ToSlash(filepath.Clean(args[0]))
+		lang, err := testgen.GetLanguage(filePath)
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		contents, err := utils.LoadFile(filePath)
+		if err != nil {
+			log.Errorf("Error loading file: %s", err)
+			os.Exit(1)
+		}
+
+		// the code the tests are for, the function or the whole file
+		code, line := contents, 0
+		if testFunction != "" {
+			sym, ok := findFunction(filePath, contents, testFunction)
+			if !ok {
+				log.Errorf("Error: the function %s was not found in %s", testFunction, filePath)
+				os.Exit(1)
+			}
+			lines := strings.Split(contents, "\n")
+			code, line = strings.Join(lines[sym.StartLine-1:sym.EndLine], "\n"), sym.Line
+		}
+
+		testPath := lang.TestPath(filePath)
+		var files []prompt.GitFile
+		testContents, err := utils.LoadFile(testPath)
+		if err == nil {
+			files = append(files, prompt.GitFile{Path: testPath, Contents: testContents})
+		} else if !os.IsNotExist(err) {
+			log.Errorf("Error loading test file: %s", err)
+			os.Exit(1)
+		}
+
+		context := []prompt.GitFile{}
+		if testPath != filePath {
+			context = append(context, prompt.GitFile{Path: filePath, Contents: contents})
+		}
+		context = append(context, relatedCode(c, filePath, code, line)...)
+
+		if dryRun || patchFile != "" {
+			log.Warn("The tests are not run for --dry-run or --patch")
+		} else {
+			verifyCommand = testCommand
+			if verifyCommand == "" {
+				verifyCommand = lang.Command(testPath)
+			}
+		}
+
+		fmt.Printf("Writing tests in %s...\n", testPath)
+		runEdit(c, files, context, lang.Goal(filePath, contents, testFunction))
+	},
+}
+
+// findFunction finds the function or method with the name, which may include the receiver like Type.Method
+func findFunction(filePath, contents, name string) (symbols.Symbol, bool) {
+	syms, err := symbols.Parse(filePath, contents)
+	if err != nil {
+		return symbols.Symbol{}, false
+	}
+	receiver, method, hasReceiver := strings.Cut(name, ".")
+	for _, sym := range syms {
+		if hasReceiver && sym.Receiver == receiver && sym.Name == method {
+			return sym, true
+		}
+		if !hasReceiver && sym.Name == name {
+			return sym, true
+		}
+	}
+	return symbols.Symbol{}, false
+}
+
+// relatedCode returns the declarations elsewhere in the repo that the code refers to, as many as
+// fit in a quarter of the context of the model
+func relatedCode(c *config.Config, filePath, code string, line int) []prompt.GitFile {
+	if !git.IsGitRepo(".") {
+		return nil
+	}
+	repo, err := git.GetRepo(".", ignoreFilePath, ignoreGitignore)
+	if err != nil {
+		log.Warnf("Error getting repo, continuing without related code: %s", err)
+		return nil
+	}
+	var repoFiles []prompt.GitFile
+	for _, file := range repo.Files {
+		repoFiles = append(repoFiles, prompt.GitFile{Path: filepath.ToSlash(file.Path), Contents: file.Contents})
+	}
+
+	budget := calc.GetMaxTokens(c.Model) / 4
+	tokens := 0
+	var related []prompt.GitFile
+	for _, sym := range routes.NewIndex(repoFiles).References(code, filePath, line) {
+		// the file itself is already sent whole
+		if sym.Path == filePath {
+			continue
+		}
+		cost := calc.EstimateTokens(sym.Code)
+		if tokens+cost > budget {
+			log.Debugf("Related code doesn't fit, leaving out %s from %s", sym.Symbol.Name, sym.Path)
+			continue
+		}
+		tokens += cost
+		related = append(related, prompt.GitFile{Path: sym.Path + " (" + sym.Symbol.Name + ")", Contents: sym.Code})
+		log.Debugf("Including %s from %s", sym.Symbol.Name, sym.Path)
+	}
+	return related
+}
+
+func init() {
+	testCmd.AddCommand(testGenCmd)
+
+	testGenCmd.Flags().StringVar(&testFunction, "func", "", "Only test this function, or a method in the form Type.Method")
+	testGenCmd.Flags().StringVar(&testCommand, "run", "", "Command that runs the tests. Defaults to the test runner of the language")
+	testGenCmd.Flags().IntVar(&maxIterations, "max-iterations", 3, "Most attempts to make the tests pass before rolling them back")
+	testGenCmd.Flags().BoolVarP(&force, "force", "f", false, "Write the tests without asking")
+	testGenCmd.Flags().StringSliceVarP(&contextFiles, "context", "c", []string{}, "Context files")
+	testGenCmd.Flags().StringVarP(&ignoreFilePath, "ignore", "n", "", "path to .gptignore file")
+	testGenCmd.Flags().BoolVarP(&ignoreGitignore, "ignore-gitignore", "g", false, "ignore .gitignore file")
+	testGenCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	addWriteFlags(testGenCmd)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2024 TimeSurgeLabs <chandler@timesurgelabs.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/journal"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/writer"
+	"github.com/spf13/cobra"
+)
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo [id]",
+	Short: "Undo the changes Otto made to files",
+	Long: `Undo the changes Otto made to files. Every file written by otto docs, otto edit and otto apiDocs is
+recorded in a journal in ~/.ottodocs/journal along with its original contents, so changes can be undone
+even for files git doesn't track. Files Otto created are deleted.
+
+Without an ID the most recent change is undone. Use --list to see the IDs. If a file was changed after Otto
+wrote it, the undo is refused so your changes aren't lost. Use --force to restore the files anyway.
+
+Example:
+otto undo --list
+otto undo
+otto undo 20240101-120000
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if listJournal {
+			entries, err := journal.List()
+			if err != nil {
+				log.Errorf("Error loading journal: %s", err)
+				os.Exit(1)
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No changes recorded.")
+				return
+			}
+
+			for _, entry := range entries {
+				status := ""
+				if entry.Undone {
+					status = " (undone)"
+				}
+				fmt.Printf("%s  %s  %d files  %s%s\n", entry.ID, entry.Time.Format("2006-01-02 15:04:05"), len(entry.Files), entry.Command, status)
+			}
+			return
+		}
+
+		var entry *journal.Entry
+		var err error
+		if len(args) > 0 {
+			entry, err = journal.Get(args[0])
+		} else {
+			entry, err = journal.Latest()
+		}
+		if err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		err = entry.Restore(force, func(path, contents string) error {
+			return writer.Atomic(path, contents, false)
+		})
+
+		var changedErr *journal.ChangedError
+		if errors.As(err, &changedErr) {
+			log.Error("These files changed after Otto wrote them:")
+			for _, file := range changedErr.Files {
+				log.Errorf("  %s", file)
+			}
+			log.Error("Use --force to undo anyway.")
+			os.Exit(1)
+		} else if err != nil {
+			log.Errorf("Error undoing %s: %s", entry.ID, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Undid %s: %s\n", entry.ID, entry.Command)
+		for _, file := range entry.Files {
+			if file.Existed {
+				fmt.Println("Restored", file.Path)
+			} else {
+				fmt.Println("Deleted", file.Path)
+			}
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(undoCmd)
+
+	undoCmd.Flags().BoolVarP(&listJournal, "list", "l", false, "List the recorded changes")
+	undoCmd.Flags().BoolVarP(&force, "force", "f", false, "Undo even if the files changed since Otto wrote them")
+}
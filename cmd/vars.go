@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"time"
 
 	l "github.com/charmbracelet/log"
 )
@@ -22,6 +23,24 @@ var chatPrompt string
 var inlineMode bool
 var markdownMode bool
 var overwriteOriginal bool
+var stripComments bool
+var sinceRef string
+var stagedOnly bool
+var noCache bool
+var siteDir string
+var overviewMode bool
+
+var dryRun bool
+var patchFile string
+var backupFiles bool
+var listJournal bool
+
+var readmeSections []string
+var checkOnly bool
+
+var coverageFormat string
+var coverageThreshold float64
+var showMissing bool
 
 var conventional bool // use conventional commits
 var noCommit bool
@@ -36,6 +55,11 @@ var ghToken string
 var remote string
 var userColor string
 var ottoColor string
+var docStyles []string
+var formatters []string
+var requestsPerMinute int
+var tokensPerMinute int
+var concurrency int
 
 var issuePRNumber int
 var useComments bool
@@ -45,12 +69,27 @@ var countFinalTokens bool
 var startLine int
 var endLine int
 var appendFile bool
+var editFilePatterns []string
+var verifyCommand string
+var maxIterations int
+var testFunction string
+var testCommand string
+var maxStderr int
 
 var previousTag string
 var currentTag string
+var publishRelease bool
+var updateRelease bool
+var releaseAssets []string
 
 var contextFiles []string
 var routerFiles []string
+var apiFormat string
+var mergeSpec bool
+var baseURL string
+var allMethods bool
+var requestHeaders []string
+var requestTimeout time.Duration
 
 var displayHistory bool
 var loadHistory string
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/journal"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/writer"
+	"github.com/spf13/cobra"
+)
+
+// addWriteFlags adds the flags that control how a command writes files
+func addWriteFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print a unified diff of the changes instead of writing them")
+	cmd.Flags().StringVar(&patchFile, "patch", "", "Save a unified diff of the changes to this file instead of writing them")
+	cmd.Flags().BoolVar(&backupFiles, "backup", false, "Keep a copy of each replaced file with a "+writer.BackupExt+" extension")
+}
+
+// newWriter creates the writer for the write flags. The files it writes are
+// recorded in the journal so otto undo can restore them.
+func newWriter() *writer.Writer {
+	w := writer.New(dryRun, patchFile, backupFiles)
+	w.Journal = journal.NewEntry(strings.Join(append([]string{"otto"}, os.Args[1:]...), " "))
+	return w
+}
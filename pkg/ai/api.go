@@ -1,7 +1,6 @@
 package ai
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
@@ -55,10 +54,6 @@ func APIEndpoints(files []string, conf *config.Config) ([]string, error) {
 		Description: "Get all the endpoints in the files",
 		Parameters:  params,
 	}
-	t := openai.Tool{
-		Type:     openai.ToolTypeFunction,
-		Function: f,
-	}
 
 	messages := []openai.ChatCompletionMessage{
 		{
@@ -71,20 +66,11 @@ func APIEndpoints(files []string, conf *config.Config) ([]string, error) {
 		},
 	}
 
-	c := makeClient(conf)
-	ctx := context.Background()
-
-	r, err := c.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:    conf.Model,
-		Messages: messages,
-		Tools:    []openai.Tool{t},
-	})
+	_, resp, err := requestFunction(messages, f, conf)
 	if err != nil {
 		return nil, err
 	}
 
-	resp := r.Choices[0].Message.Content
-
 	// parse the response
 	var endpoints endpointsResp
 	err = json.Unmarshal([]byte(resp), &endpoints)
@@ -103,7 +89,7 @@ func APIDocumentEndpoint(endpoint string, files []string, conf *config.Config) (
 	}
 
 	// ask the AI to document the endpoint
-	resp, err := request(constants.API_DOCUMENT_ENDPOINT_PROMPT, fileStr, conf)
+	resp, err := request(constants.API_DOCUMENT_ENDPOINT_PROMPT, "Endpoint: "+endpoint+"\n\n"+fileStr, conf)
 	if err != nil {
 		return "", err
 	}
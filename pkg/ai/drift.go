@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/drift"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// DriftResult is whether a pair of documentation and code still match
+type DriftResult struct {
+	Pair    drift.Pair
+	Matches bool
+	Problem string
+}
+
+type driftCheck struct {
+	ID      int    `json:"id"`
+	Matches bool   `json:"matches"`
+	Problem string `json:"problem"`
+}
+
+type driftChecksResp struct {
+	Checks []driftCheck `json:"checks"`
+}
+
+var checkDriftFunction = openai.FunctionDefinition{
+	Name:        "report_checks",
+	Description: "Report whether each pair of documentation and code still match",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"checks": {
+				Type:        jsonschema.Array,
+				Description: "One check for each pair",
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"id": {
+							Type:        jsonschema.Integer,
+							Description: "The ID of the pair",
+						},
+						"matches": {
+							Type:        jsonschema.Boolean,
+							Description: "Whether the documentation still matches the code",
+						},
+						"problem": {
+							Type:        jsonschema.String,
+							Description: "What is wrong with the documentation. Empty if it matches",
+						},
+					},
+					Required: []string{"id", "matches", "problem"},
+				},
+			},
+		},
+		Required: []string{"checks"},
+	},
+}
+
+// CheckDrift asks the model whether each documentation still matches the code it describes.
+// Pairs are sent in batches that fit in half of the model's context. Pairs the model doesn't
+// answer for are left out of the results.
+func CheckDrift(pairs []drift.Pair, conf *config.Config) ([]DriftResult, error) {
+	budget := calc.GetMaxTokens(conf.Model) / 2
+
+	var results []DriftResult
+	for start := 0; start < len(pairs); {
+		var b strings.Builder
+		end := start
+		for end < len(pairs) {
+			pair := pairs[end]
+			text := fmt.Sprintf("Pair %d\nDocumentation in %s:\n%s\n\nCode of %s in %s:\n%s\n\n", end, pair.Location(), pair.Doc, pair.Symbol.Symbol.Key(), pair.Symbol.Path, pair.Symbol.Code)
+			// always send at least one pair
+			if end > start && calc.EstimateTokens(b.String(), text) > budget {
+				break
+			}
+			b.WriteString(text)
+			end++
+		}
+
+		batch, err := checkDriftBatch(b.String(), pairs, start, end, conf)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+		start = end
+	}
+
+	return results, nil
+}
+
+func checkDriftBatch(question string, pairs []drift.Pair, start, end int, conf *config.Config) ([]DriftResult, error) {
+	messages := []openai.ChatCompletionMessage{
+		{
+			Content: constants.CHECK_DRIFT_PROMPT,
+			Role:    openai.ChatMessageRoleSystem,
+		},
+		{
+			Content: question,
+			Role:    openai.ChatMessageRoleUser,
+		},
+	}
+
+	_, args, err := requestFunction(messages, checkDriftFunction, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp driftChecksResp
+	err = json.Unmarshal([]byte(args), &resp)
+	if err != nil {
+		return nil, fmt.Errorf("the checks are not valid JSON: %s", err)
+	}
+
+	seen := map[int]bool{}
+	var results []DriftResult
+	for _, check := range resp.Checks {
+		if check.ID < start || check.ID >= end || seen[check.ID] {
+			continue
+		}
+		seen[check.ID] = true
+		results = append(results, DriftResult{
+			Pair:    pairs[check.ID],
+			Matches: check.Matches,
+			Problem: strings.TrimSpace(check.Problem),
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,226 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/edits"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/postedit"
+	"github.com/chand1012/git2gpt/prompt"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// how many times the model is asked to correct edits that couldn't be applied
+const maxEditRetries = 2
+
+type editResp struct {
+	Explanation string       `json:"explanation"`
+	Edits       []edits.Edit `json:"edits"`
+}
+
+var editFunction = openai.FunctionDefinition{
+	Name:        "edit_files",
+	Description: "Edit the files with search and replace blocks",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"explanation": {Type: jsonschema.String, Description: "A short explanation of the changes"},
+			"edits": {
+				Type:        jsonschema.Array,
+				Description: "The edits, applied in order",
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"path":    {Type: jsonschema.String, Description: "The path of the file, exactly as given"},
+						"search":  {Type: jsonschema.String, Description: "The code to replace, copied exactly from the file. Empty to create a file"},
+						"replace": {Type: jsonschema.String, Description: "The code to replace it with"},
+					},
+					Required: []string{"path", "search", "replace"},
+				},
+			},
+		},
+		Required: []string{"explanation", "edits"},
+	},
+}
+
+// FileEditor asks the model for edits to a set of files. The conversation is kept so the
+// edits can be refined.
+type FileEditor struct {
+	// checks and formats the edited files, returning *postedit.SyntaxError for code that doesn't parse
+	Check func(path, contents string) (string, error)
+
+	conf     *config.Config
+	messages []openai.ChatCompletionMessage
+	// the last function call, answered by the next request
+	call *openai.ChatCompletionMessage
+}
+
+// NewFileEditor starts editing the files towards the goal. The context files are sent along,
+// but can't be edited.
+func NewFileEditor(goal string, files, context []prompt.GitFile, conf *config.Config) *FileEditor {
+	var b strings.Builder
+	b.WriteString("GOAL: " + goal + "\n\n")
+	for _, file := range files {
+		fmt.Fprintf(&b, "FILE: %s\n%s\n\n", file.Path, file.Contents)
+	}
+	for _, file := range context {
+		fmt.Fprintf(&b, "CONTEXT: %s\n%s\n\n", file.Path, file.Contents)
+	}
+
+	return &FileEditor{
+		conf: conf,
+		messages: []openai.ChatCompletionMessage{
+			{
+				Content: constants.EDIT_FILES_PROMPT,
+				Role:    openai.ChatMessageRoleSystem,
+			},
+			{
+				Content: b.String(),
+				Role:    openai.ChatMessageRoleUser,
+			},
+		},
+	}
+}
+
+// request requests the edits from the model, along with its explanation of them
+func (e *FileEditor) request() ([]edits.Edit, string, error) {
+	call, args, err := requestFunction(e.messages, editFunction, e.conf)
+	if err != nil {
+		return nil, "", err
+	}
+	e.messages = append(e.messages, call)
+	e.call = &call
+
+	var resp editResp
+	err = json.Unmarshal([]byte(args), &resp)
+	if err != nil {
+		return nil, "", fmt.Errorf("the model returned invalid edits: %s", err)
+	}
+	// the model sometimes wraps code in markdown even when it's told not to. In markdown files
+	// the fences can be what the edit is meant to write.
+	for i := range resp.Edits {
+		if postedit.IsMarkdown(resp.Edits[i].Path) {
+			continue
+		}
+		resp.Edits[i].Search = postedit.StripFences(resp.Edits[i].Search)
+		resp.Edits[i].Replace = postedit.StripFences(resp.Edits[i].Replace)
+	}
+	return resp.Edits, resp.Explanation, nil
+}
+
+// CheckError is returned by Changes when the edits leave syntax errors
+type CheckError struct {
+	// the errors with the code around them
+	Problems string
+}
+
+func (e *CheckError) Error() string {
+	return "the edits left syntax errors:\n" + e.Problems
+}
+
+// Changes requests the edits and applies them to the files, which are the contents by path.
+// Edits that can't be applied are sent back to the model to be corrected, keeping the ones that
+// could, and so are syntax errors found by Check. Returns the changes along with the explanation
+// of the model, and an *edits.ApplyError or a *CheckError if they still couldn't be fixed.
+func (e *FileEditor) Changes(files map[string]string, exists func(path string) bool) (*edits.ChangeSet, string, error) {
+	changes := edits.NewChangeSet(files)
+	fileEdits, explanation, err := e.request()
+	if err != nil {
+		return nil, "", err
+	}
+
+	for attempt := 0; ; attempt++ {
+		var feedback string
+		err = changes.Apply(fileEdits, exists)
+		var applyErr *edits.ApplyError
+		if errors.As(err, &applyErr) {
+			feedback = "Some of the edits could not be applied, the others were.\n\n" + applyErr.Feedback() + "Call the function again with only the failed edits, corrected so the search text is copied exactly from the file as it is after the other edits."
+		} else {
+			var problems string
+			problems, err = e.check(changes)
+			if err != nil || problems == "" {
+				return changes, explanation, err
+			}
+			err = &CheckError{Problems: problems}
+			feedback = "The edits were applied, but they left syntax errors:\n\n" + problems + "\nCall the function again with edits to the files as they are now that fix the errors."
+		}
+
+		if attempt == maxEditRetries {
+			return changes, explanation, err
+		}
+		e.reply(feedback)
+		fileEdits, _, err = e.request()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+}
+
+// check runs Check on the changed files, replacing them with the formatted contents. Returns
+// the syntax errors with the code around them. Files that had errors before they were edited
+// aren't reported, as the edit didn't cause them.
+func (e *FileEditor) check(changes *edits.ChangeSet) (string, error) {
+	if e.Check == nil {
+		return "", nil
+	}
+
+	var b strings.Builder
+	for _, path := range changes.Paths() {
+		contents := changes.Updated[path]
+		formatted, err := e.Check(path, contents)
+		if err == nil {
+			changes.Updated[path] = formatted
+			continue
+		}
+
+		var syntaxErr *postedit.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return "", err
+		}
+		if !changes.Created(path) {
+			if _, err := e.Check(path, changes.Original[path]); err != nil {
+				continue
+			}
+		}
+
+		b.WriteString(err.Error() + "\n")
+		if syntaxErr.Line > 0 {
+			b.WriteString(postedit.Excerpt(contents, syntaxErr.Line))
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// reply answers the last function call, or adds a message if it was answered already
+func (e *FileEditor) reply(message string) {
+	if e.call == nil {
+		e.messages = append(e.messages, openai.ChatCompletionMessage{Content: message, Role: openai.ChatMessageRoleUser})
+		return
+	}
+	e.messages = append(e.messages, toolResult(*e.call, message))
+	e.call = nil
+}
+
+// Discard tells the model its last edits weren't kept, so the next edits are made to the files
+// as they were before them
+func (e *FileEditor) Discard(reason string) {
+	e.reply("The edits were not applied because they could not be fixed:\n\n" + reason + "\n\nThe files are unchanged.")
+}
+
+// Refine asks for the edits to be changed with the feedback, on the next call to Changes. The
+// edits replace the previous ones, so they're made to the original files again.
+func (e *FileEditor) Refine(feedback string) {
+	e.reply("The edits were not applied. Call the function again with all the edits to the original files, changed with the following input: " + feedback)
+}
+
+// Fix asks for edits that fix the failure of the verification command, on the next call to
+// Changes. The edits are made to the files as they are after the previous edits.
+func (e *FileEditor) Fix(command, output string) {
+	e.reply(fmt.Sprintf("The edits were applied, but the command `%s` failed with the following output:\n\n%s\n\nCall the function again with edits to the files as they are now that fix the failure.", command, output))
+}
@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/shell"
+)
+
+// FixCommand explains why the last command failed and suggests a corrected one.
+// The history is the commands run before it, oldest first.
+func FixCommand(last *shell.LastCommand, history []string, chatPrompt string, conf *config.Config) (*openai.ChatCompletionStream, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Command: %s\nExit code: %d\n", last.Command, last.ExitCode)
+	if last.Dir != "" {
+		fmt.Fprintf(&b, "Directory: %s\n", last.Dir)
+	}
+	if last.Stderr != "" {
+		fmt.Fprintf(&b, "Error output:\n%s\n", last.Stderr)
+	} else {
+		b.WriteString("Error output: not captured\n")
+	}
+	if chatPrompt != "" {
+		fmt.Fprintf(&b, "\nNote from the user: %s\n", chatPrompt)
+	}
+
+	tokens := calc.EstimateTokens(constants.FIX_COMMAND_PROMPT + b.String())
+	// leave room for the answer
+	budget := calc.GetMaxTokens(conf.Model) - 500
+
+	// add the most recent history that fits, keeping it in order
+	var recent []string
+	for i := len(history) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(history[i])
+		if line == "" {
+			continue
+		}
+		lineTokens := calc.EstimateTokens(line + "\n")
+		if tokens+lineTokens > budget {
+			break
+		}
+		tokens += lineTokens
+		recent = append([]string{line}, recent...)
+	}
+	if len(recent) > 0 {
+		b.WriteString("\nCommands run before it, oldest first:\n" + strings.Join(recent, "\n") + "\n")
+	}
+
+	return requestStream(constants.FIX_COMMAND_PROMPT, b.String(), conf)
+}
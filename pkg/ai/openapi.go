@@ -0,0 +1,171 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// how many times the model is asked to fix an invalid operation
+const maxOperationRetries = 2
+
+type bodyResp struct {
+	Status      string          `json:"status"`
+	Description string          `json:"description"`
+	Required    bool            `json:"required"`
+	ContentType string          `json:"contentType"`
+	Schema      *openapi.Schema `json:"schema"`
+}
+
+type operationResp struct {
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	OperationID string               `json:"operationId"`
+	Tags        []string             `json:"tags"`
+	Parameters  []*openapi.Parameter `json:"parameters"`
+	RequestBody *bodyResp            `json:"requestBody"`
+	Responses   []bodyResp           `json:"responses"`
+}
+
+var schemaDefinition = jsonschema.Definition{
+	Type:        jsonschema.Object,
+	Description: "A JSON Schema, for example {\"type\": \"object\", \"properties\": {\"name\": {\"type\": \"string\"}}, \"required\": [\"name\"]}",
+}
+
+var operationFunction = openai.FunctionDefinition{
+	Name:        "document_operation",
+	Description: "Document the operation of the endpoint as part of an OpenAPI 3.1 specification",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"summary":     {Type: jsonschema.String, Description: "A short summary of the operation"},
+			"description": {Type: jsonschema.String, Description: "What the operation does and how to use it"},
+			"operationId": {Type: jsonschema.String, Description: "A unique camelCase name for the operation"},
+			"tags": {
+				Type:        jsonschema.Array,
+				Description: "Tags to group the operation by, usually the resource",
+				Items:       &jsonschema.Definition{Type: jsonschema.String},
+			},
+			"parameters": {
+				Type:        jsonschema.Array,
+				Description: "The path, query, header and cookie parameters",
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"name":        {Type: jsonschema.String},
+						"in":          {Type: jsonschema.String, Enum: []string{"path", "query", "header", "cookie"}},
+						"description": {Type: jsonschema.String},
+						"required":    {Type: jsonschema.Boolean},
+						"schema":      schemaDefinition,
+					},
+					Required: []string{"name", "in", "required", "schema"},
+				},
+			},
+			"requestBody": {
+				Type:        jsonschema.Object,
+				Description: "The body of the request. Leave out if the operation has no body",
+				Properties: map[string]jsonschema.Definition{
+					"description": {Type: jsonschema.String},
+					"required":    {Type: jsonschema.Boolean},
+					"contentType": {Type: jsonschema.String, Description: "The media type, for example application/json"},
+					"schema":      schemaDefinition,
+				},
+				Required: []string{"contentType", "schema"},
+			},
+			"responses": {
+				Type:        jsonschema.Array,
+				Description: "Every response the operation can return",
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"status":      {Type: jsonschema.String, Description: "The HTTP status code, for example \"200\""},
+						"description": {Type: jsonschema.String},
+						"contentType": {Type: jsonschema.String, Description: "The media type of the body. Leave out if there is no body"},
+						"schema":      schemaDefinition,
+					},
+					Required: []string{"status", "description"},
+				},
+			},
+		},
+		Required: []string{"summary", "description", "operationId", "parameters", "responses"},
+	},
+}
+
+// toOperation converts the arguments of the function call to an operation
+func (r operationResp) toOperation() *openapi.Operation {
+	op := &openapi.Operation{
+		Summary:     r.Summary,
+		Description: r.Description,
+		OperationID: r.OperationID,
+		Tags:        r.Tags,
+		Parameters:  r.Parameters,
+		Responses:   map[string]*openapi.Response{},
+	}
+
+	if r.RequestBody != nil && r.RequestBody.ContentType != "" {
+		op.RequestBody = &openapi.RequestBody{
+			Description: r.RequestBody.Description,
+			Required:    r.RequestBody.Required,
+			Content:     map[string]*openapi.MediaType{r.RequestBody.ContentType: {Schema: r.RequestBody.Schema}},
+		}
+	}
+
+	for _, response := range r.Responses {
+		resp := &openapi.Response{Description: response.Description}
+		if response.ContentType != "" {
+			resp.Content = map[string]*openapi.MediaType{response.ContentType: {Schema: response.Schema}}
+		}
+		op.Responses[response.Status] = resp
+	}
+
+	return op
+}
+
+// APIOperation asks the model for the OpenAPI operation of the endpoint. The operation is
+// validated and the problems are sent back to the model until it's valid.
+func APIOperation(method, path string, files []string, conf *config.Config) (*openapi.Operation, error) {
+	question := fmt.Sprintf("Endpoint: %s %s\n\n%s", strings.ToUpper(method), path, strings.Join(files, ""))
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Content: constants.API_OPENAPI_OPERATION_PROMPT,
+			Role:    openai.ChatMessageRoleSystem,
+		},
+		{
+			Content: question,
+			Role:    openai.ChatMessageRoleUser,
+		},
+	}
+
+	var problems []string
+	for attempt := 0; attempt <= maxOperationRetries; attempt++ {
+		call, args, err := requestFunction(messages, operationFunction, conf)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp operationResp
+		err = json.Unmarshal([]byte(args), &resp)
+		if err != nil {
+			problems = []string{fmt.Sprintf("the arguments are not valid JSON: %s", err)}
+		} else {
+			op := resp.toOperation()
+			openapi.FillPathParameters(path, op)
+			problems = openapi.ValidateOperation(method, path, op, nil)
+			if len(problems) == 0 {
+				return op, nil
+			}
+		}
+
+		// send the problems back so the model can correct them
+		messages = append(messages, call, toolResult(call, "The operation was rejected for the following reasons:\n- "+strings.Join(problems, "\n- ")+"\nCall the function again with the whole operation, fixing these problems."))
+	}
+
+	return nil, fmt.Errorf("invalid operation: %s", strings.Join(problems, "; "))
+}
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/depgraph"
+)
+
+// SummarizeModule summarizes a directory of the repository from its files and the summaries of its subdirectories.
+// contents maps file paths to their contents. Files that don't fit in half of the model's context are only listed by name.
+func SummarizeModule(module *depgraph.Module, contents map[string]string, childSummaries map[string]string, conf *config.Config) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Directory: %s\n\n", module.Path)
+
+	if len(module.Imports) > 0 {
+		fmt.Fprintf(&b, "Depends on: %s\n\n", strings.Join(module.Imports, ", "))
+	}
+	if len(module.External) > 0 {
+		fmt.Fprintf(&b, "External dependencies: %s\n\n", strings.Join(module.External, ", "))
+	}
+
+	for _, child := range module.Children {
+		if summary, ok := childSummaries[child]; ok {
+			fmt.Fprintf(&b, "Subdirectory %s: %s\n\n", child, summary)
+		}
+	}
+
+	budget := calc.GetMaxTokens(conf.Model) / 2
+	var skipped []string
+	for _, file := range module.Files {
+		fileContents := contents[file]
+		if calc.EstimateTokens(b.String(), fileContents) > budget {
+			skipped = append(skipped, file)
+			continue
+		}
+		fmt.Fprintf(&b, "File %s:\n\n%s\n\n", file, strings.TrimRight(fileContents, " \n"))
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "Other files: %s\n", strings.Join(skipped, ", "))
+	}
+
+	return request(constants.SUMMARIZE_MODULE_PROMPT, b.String(), conf)
+}
+
+// ArchitectureOverview writes an overview of the repository from the summaries of its directories
+func ArchitectureOverview(graph *depgraph.Graph, summaries map[string]string, conf *config.Config) (string, error) {
+	var b strings.Builder
+	for _, p := range graph.Paths() {
+		summary, ok := summaries[p]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "Directory %s", p)
+		if imports := graph.Modules[p].Imports; len(imports) > 0 {
+			fmt.Fprintf(&b, " (depends on %s)", strings.Join(imports, ", "))
+		}
+		fmt.Fprintf(&b, ": %s\n\n", summary)
+	}
+
+	return request(constants.ARCHITECTURE_OVERVIEW_PROMPT, b.String(), conf)
+}
@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/readme"
+)
+
+// ReadmeSection writes a section of the README from its source files. current is the current
+// version of the section, if there is one. Sources that don't fit in half of the model's
+// context are only listed by name.
+func ReadmeSection(name string, kind readme.Kind, sources map[string]string, current string, conf *config.Config) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Project: %s\n\nSection heading: ## %s\n\nThe section should: %s\n\n", name, kind.Title, kind.Instructions)
+	if current != "" {
+		fmt.Fprintf(&b, "Current version of the section:\n\n%s\n\n", current)
+	}
+
+	var paths []string
+	for path := range sources {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	budget := calc.GetMaxTokens(conf.Model) / 2
+	var skipped []string
+	for _, path := range paths {
+		if calc.EstimateTokens(b.String(), sources[path]) > budget {
+			skipped = append(skipped, path)
+			continue
+		}
+		fmt.Fprintf(&b, "File %s:\n\n%s\n\n", path, strings.TrimRight(sources[path], " \n"))
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "Other files: %s\n", strings.Join(skipped, ", "))
+	}
+
+	section, err := request(constants.README_SECTION_PROMPT, b.String(), conf)
+	if err != nil {
+		return "", err
+	}
+
+	section = strings.TrimSpace(section)
+	// models like to wrap markdown in a code block
+	if strings.HasPrefix(section, "```") && strings.HasSuffix(section, "```") {
+		section = strings.TrimSpace(strings.TrimSuffix(section[strings.Index(section, "\n")+1:], "```"))
+	}
+	if !strings.HasPrefix(section, "## ") {
+		section = "## " + kind.Title + "\n\n" + section
+	}
+	return section, nil
+}
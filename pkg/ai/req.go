@@ -3,11 +3,18 @@ package ai
 import (
 	"context"
 	"errors"
+	"sync"
 
+	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/ratelimit"
 	"github.com/sashabaranov/go-openai"
 )
 
+// shared by every request so concurrent callers stay within the limits together
+var limiter *ratelimit.Limiter
+var limiterOnce sync.Once
+
 func makeClient(conf *config.Config) *openai.Client {
 	config := openai.DefaultConfig(conf.APIKey)
 	config.OrgID = conf.Org
@@ -18,6 +25,19 @@ func makeClient(conf *config.Config) *openai.Client {
 	return openai.NewClientWithConfig(config)
 }
 
+// waitForRateLimit blocks until the request fits within the rate limits in the config
+func waitForRateLimit(req openai.ChatCompletionRequest, conf *config.Config) {
+	limiterOnce.Do(func() {
+		limiter = ratelimit.New(conf.RequestsPerMinute, conf.TokensPerMinute)
+	})
+
+	tokens := 0
+	for _, message := range req.Messages {
+		tokens += calc.EstimateTokens(message.Content)
+	}
+	limiter.Wait(tokens)
+}
+
 func request(systemMsg, userMsg string, conf *config.Config) (string, error) {
 	c := makeClient(conf)
 
@@ -37,6 +57,8 @@ func request(systemMsg, userMsg string, conf *config.Config) (string, error) {
 		},
 	}
 
+	waitForRateLimit(req, conf)
+
 	resp, err := c.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return "", err
@@ -68,6 +90,8 @@ func requestStream(systemMsg, userMsg string, conf *config.Config) (*openai.Chat
 		},
 	}
 
+	waitForRateLimit(req, conf)
+
 	return c.CreateChatCompletionStream(ctx, req)
 }
 
@@ -86,6 +110,8 @@ func SimpleRequest(prompt string, conf *config.Config) (string, error) {
 		},
 	}
 
+	waitForRateLimit(req, conf)
+
 	resp, err := c.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return "", err
@@ -113,5 +139,7 @@ func SimpleStreamRequest(prompt string, conf *config.Config) (*openai.ChatComple
 		},
 	}
 
+	waitForRateLimit(req, conf)
+
 	return c.CreateChatCompletionStream(ctx, req)
 }
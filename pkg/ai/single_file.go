@@ -1,75 +1,293 @@
 package ai
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/docstyle"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/symbols"
 	"github.com/TimeSurgeLabs/ottodocs/pkg/textfile"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
 )
 
-func extractLineNumber(line string) (int, error) {
-	// if the line does not contain a range, return the line number
-	if !strings.Contains(line, "-") {
-		lineNumber, err := strconv.Atoi(line)
-		if err != nil {
-			return -1, fmt.Errorf("could not parse line number: %s", err)
+// the number of times the model is asked to fix invalid comments
+const maxDocRetries = 2
+
+type docComment struct {
+	Line    int    `json:"line"`
+	Symbol  string `json:"symbol"`
+	Comment string `json:"comment"`
+}
+
+// WrittenComment is a doc comment SingleFile wrote into the file
+type WrittenComment struct {
+	Symbol string
+	Lines  []string
+}
+
+type docCommentsResp struct {
+	Comments []docComment `json:"comments"`
+}
+
+var documentFunction = openai.FunctionDefinition{
+	Name:        "document_declarations",
+	Description: "Add doc comments to the declarations in the file",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"comments": {
+				Type:        jsonschema.Array,
+				Description: "One doc comment for each declaration",
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"line": {
+							Type:        jsonschema.Integer,
+							Description: "The line number the declaration is on",
+						},
+						"symbol": {
+							Type:        jsonschema.String,
+							Description: "The name of the declaration, exactly as given in the list of declarations",
+						},
+						"comment": {
+							Type:        jsonschema.String,
+							Description: "The doc comment without comment markers",
+						},
+					},
+					Required: []string{"line", "symbol", "comment"},
+				},
+			},
+		},
+		Required: []string{"comments"},
+	},
+}
+
+// matchComments validates the comments against the file and matches them to the symbols
+// they document. Returns the comment for each documented symbol index and the problems found.
+func matchComments(comments []docComment, syms []symbols.Symbol, lineCount int) (map[int]string, []string) {
+	matched := map[int]string{}
+	var problems []string
+
+	for _, comment := range comments {
+		if comment.Line < 1 || comment.Line > lineCount {
+			problems = append(problems, fmt.Sprintf("line %d for %s is out of range, the file has %d lines", comment.Line, comment.Symbol, lineCount))
+			continue
+		}
+		if strings.TrimSpace(comment.Comment) == "" {
+			problems = append(problems, fmt.Sprintf("the comment for %s is empty", comment.Symbol))
+			continue
+		}
+
+		// find the closest declaration with that name that hasn't been documented yet
+		best := -1
+		found := false
+		for i, sym := range syms {
+			if sym.Key() != comment.Symbol && sym.Name != comment.Symbol {
+				continue
+			}
+			found = true
+			if _, ok := matched[i]; ok {
+				continue
+			}
+			if best == -1 || abs(sym.Line-comment.Line) < abs(syms[best].Line-comment.Line) {
+				best = i
+			}
 		}
 
-		return lineNumber, nil
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s is not one of the declarations to document", comment.Symbol))
+			continue
+		}
+		if best == -1 {
+			problems = append(problems, fmt.Sprintf("%s was documented more than once", comment.Symbol))
+			continue
+		}
+
+		sym := syms[best]
+		start := sym.StartLine
+		if sym.DocStart > 0 {
+			start = sym.DocStart
+		}
+		if comment.Line < start || comment.Line > sym.EndLine {
+			problems = append(problems, fmt.Sprintf("line %d is not part of %s, which is declared on line %d", comment.Line, comment.Symbol, sym.Line))
+			continue
+		}
+
+		matched[best] = strings.TrimSpace(comment.Comment)
 	}
 
-	// if the line contains a range, return the first line number
-	lineNumber, err := strconv.Atoi(strings.Split(line, "-")[0])
-	if err != nil {
-		return -1, fmt.Errorf("could not parse line number: %s", err)
+	return matched, problems
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// formats the comment text as a doc comment for the symbol
+func formatComment(sym symbols.Symbol, ext, commentOperator, text string, style *docstyle.Style) []string {
+	if ext == ".go" && !strings.HasPrefix(text, sym.Name+" ") {
+		// Go doc comments start with the name of the symbol
+		runes := []rune(text)
+		if len(runes) > 0 && !(len(runes) > 1 && unicode.IsUpper(runes[1])) {
+			runes[0] = unicode.ToLower(runes[0])
+		}
+		text = sym.Name + " " + string(runes)
 	}
 
-	return lineNumber, nil
+	if style.Docstring {
+		return style.Format(sym.BodyIndent, commentOperator, text)
+	}
+	return style.Format(sym.Indent, commentOperator, text)
+}
+
+// documentable returns the symbols a comment can be written for in the style. Docstrings go
+// inside the body, so one line declarations like "def f(): return 1" are skipped.
+func documentable(syms []symbols.Symbol, style *docstyle.Style) []symbols.Symbol {
+	if !style.Docstring {
+		return syms
+	}
+	var filtered []symbols.Symbol
+	for _, sym := range syms {
+		if sym.BodyStart > 0 {
+			filtered = append(filtered, sym)
+		}
+	}
+	return filtered
+}
+
+// Document a file using the OpenAI Otto API. Doc comments are written above each exported
+// declaration, or inside it for docstring styles, replacing the existing doc comment if there is one.
+// Invalid comments are sent back to the model to be fixed. If some are still invalid after the retries,
+// only the valid ones are written.
+func SingleFile(filePath, contents, chatPrompt string, conf *config.Config) (string, []WrittenComment, error) {
+	return SingleFileSymbols(filePath, contents, chatPrompt, nil, conf)
 }
 
-// Document a file using the OpenAI Otto API. Takes a file path, a prompt, and an API key as arguments.
-func SingleFile(filePath, contents, chatPrompt string, conf *config.Config) (string, error) {
+// SingleFileSymbols is like SingleFile, but only documents the symbols with the given keys.
+// If only is nil all exported symbols are documented.
+func SingleFileSymbols(filePath, contents, chatPrompt string, only map[string]bool, conf *config.Config) (string, []WrittenComment, error) {
 
 	fileEnding := filepath.Ext(filePath)
 
 	commentOperator, ok := constants.CommentOperators[fileEnding]
 	if !ok {
-		return "", fmt.Errorf("the file type %s is not supported", fileEnding)
+		return "", nil, fmt.Errorf("the file type %s is not supported", fileEnding)
 	}
 
-	question := chatPrompt + "\n\n" + strings.TrimRight(contents, " \n")
+	style, err := docstyle.ForFile(filePath, conf.DocStyles)
+	if err != nil {
+		return "", nil, err
+	}
 
-	message, err := request(constants.DOCUMENT_FILE_PROMPT, question, conf)
+	syms, err := symbols.Parse(filePath, contents)
 	if err != nil {
-		return "", err
+		return "", nil, fmt.Errorf("could not parse file: %s", err)
+	}
+
+	syms = documentable(symbols.Exported(syms), style)
+	if only != nil {
+		var filtered []symbols.Symbol
+		for _, sym := range syms {
+			if only[sym.Key()] {
+				filtered = append(filtered, sym)
+			}
+		}
+		syms = filtered
+	}
+	if len(syms) == 0 {
+		return contents, nil, nil
 	}
 
-	lineNumbers := []int{}
-	comments := []string{}
+	declarations := "Declarations to document:\n"
+	for _, sym := range syms {
+		declarations += fmt.Sprintf("- %s (%s)\n", sym.Key(), sym.Kind)
+	}
 
-	for _, line := range strings.Split(message, "\n") {
-		if line == "" {
-			continue
+	lines := strings.Split(contents, "\n")
+	numbered := ""
+	for i, line := range lines {
+		numbered += fmt.Sprintf("%d | %s\n", i+1, line)
+	}
+
+	question := chatPrompt + "\n\n" + strings.TrimRight(numbered, " \n") + "\n\n" + declarations + "\nComment style: " + style.Guide
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Content: constants.DOCUMENT_FILE_PROMPT,
+			Role:    openai.ChatMessageRoleSystem,
+		},
+		{
+			Content: question,
+			Role:    openai.ChatMessageRoleUser,
+		},
+	}
+
+	var matched map[int]string
+	var problems []string
+	for attempt := 0; attempt <= maxDocRetries; attempt++ {
+		call, args, err := requestFunction(messages, documentFunction, conf)
+		if err != nil {
+			return "", nil, err
 		}
-		splits := strings.Split(line, ": ")
 
-		lineNumber, err := extractLineNumber(splits[0])
+		var resp docCommentsResp
+		err = json.Unmarshal([]byte(args), &resp)
 		if err != nil {
-			return "", fmt.Errorf("could not parse line number: %s", err)
+			problems = []string{fmt.Sprintf("the arguments are not valid JSON: %s", err)}
+		} else {
+			matched, problems = matchComments(resp.Comments, syms, len(lines))
+		}
+
+		if len(problems) == 0 {
+			break
 		}
 
-		lineNumbers = append(lineNumbers, lineNumber)
-		comments = append(comments, commentOperator+" "+splits[1])
+		// send the problems back so the model can correct them
+		messages = append(messages, call, toolResult(call, "The comments were rejected for the following reasons:\n- "+strings.Join(problems, "\n- ")+"\nCall the function again with all of the comments, fixing these problems."))
+	}
+
+	// use whatever was valid on the last attempt
+	if len(matched) == 0 && len(problems) > 0 {
+		return "", nil, fmt.Errorf("invalid comments: %s", strings.Join(problems, "; "))
+	}
+
+	var edits []textfile.Edit
+	var written []WrittenComment
+	for i, sym := range syms {
+		comment, ok := matched[i]
+		if !ok {
+			continue
+		}
+
+		lines := formatComment(sym, fileEnding, commentOperator, comment, style)
+		edit := textfile.Edit{Start: sym.StartLine, End: sym.StartLine - 1, Lines: lines}
+		if style.Docstring && sym.BodyStart > 0 {
+			edit = textfile.Edit{Start: sym.BodyStart, End: sym.BodyStart - 1, Lines: lines}
+			if sym.DocstringStart > 0 {
+				edit.Start = sym.DocstringStart
+				edit.End = sym.DocstringEnd
+			}
+		} else if sym.DocStart > 0 {
+			edit.Start = sym.DocStart
+			edit.End = sym.DocEnd
+		}
+		edits = append(edits, edit)
+		written = append(written, WrittenComment{Symbol: sym.Key(), Lines: lines})
 	}
 
-	newContents, err := textfile.InsertLinesAtIndices(string(contents), lineNumbers, comments)
+	newContents, err := textfile.ApplyEdits(contents, edits)
 	if err != nil {
-		return "", fmt.Errorf("could not insert comments: %s", err)
+		return "", nil, fmt.Errorf("could not insert comments: %s", err)
 	}
 
-	return newContents, nil
+	return newContents, written, nil
 }
@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/docstyle"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/symbols"
+)
+
+func TestMatchComments(t *testing.T) {
+	syms := []symbols.Symbol{
+		{Name: "Load", Kind: "func", Line: 3, StartLine: 3, EndLine: 10},
+		{Name: "Save", Receiver: "Config", Kind: "method", Line: 12, StartLine: 12, EndLine: 20, DocStart: 11, DocEnd: 11},
+		{Name: "Save", Kind: "func", Line: 22, StartLine: 22, EndLine: 25},
+	}
+
+	testCases := []struct {
+		name             string
+		comments         []docComment
+		expectedMatched  map[int]string
+		expectedProblems int
+	}{
+		{
+			name: "All valid",
+			comments: []docComment{
+				{Line: 3, Symbol: "Load", Comment: "Load loads."},
+				{Line: 11, Symbol: "Config.Save", Comment: "Save saves the config."},
+				{Line: 22, Symbol: "Save", Comment: "Save saves."},
+			},
+			expectedMatched:  map[int]string{0: "Load loads.", 1: "Save saves the config.", 2: "Save saves."},
+			expectedProblems: 0,
+		},
+		{
+			name: "Duplicate names use the closest line",
+			comments: []docComment{
+				{Line: 23, Symbol: "Save", Comment: "Save saves."},
+			},
+			expectedMatched:  map[int]string{2: "Save saves."},
+			expectedProblems: 0,
+		},
+		{
+			name: "Invalid comments",
+			comments: []docComment{
+				{Line: 40, Symbol: "Load", Comment: "Load loads."},
+				{Line: 3, Symbol: "Missing", Comment: "Missing is missing."},
+				{Line: 3, Symbol: "Load", Comment: " "},
+				{Line: 15, Symbol: "Load", Comment: "Load loads."},
+			},
+			expectedMatched:  map[int]string{},
+			expectedProblems: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matched, problems := matchComments(tc.comments, syms, 30)
+
+			if len(problems) != tc.expectedProblems {
+				t.Errorf("Expected %d problems, but got %d: %v", tc.expectedProblems, len(problems), problems)
+			}
+
+			if len(matched) != len(tc.expectedMatched) {
+				t.Fatalf("Expected %d matches, but got %d: %v", len(tc.expectedMatched), len(matched), matched)
+			}
+
+			for i, comment := range tc.expectedMatched {
+				if matched[i] != comment {
+					t.Errorf("Expected comment %q for symbol %d, but got %q", comment, i, matched[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDocumentableSkipsOneLineDocstringDeclarations(t *testing.T) {
+	contents := "def one(): return 1\n\n\ndef two():\n    return 2\n"
+	syms, err := symbols.Parse("example.py", contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	style, err := docstyle.ForFile("example.py", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := documentable(symbols.Exported(syms), style)
+	if len(got) != 1 || got[0].Name != "two" {
+		t.Errorf("Expected only two to be documentable, but got %+v", got)
+	}
+}
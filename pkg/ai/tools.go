@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
+	"github.com/sashabaranov/go-openai"
+)
+
+// requestFunction forces the model to call the function and returns the assistant
+// message along with the JSON arguments it called the function with.
+func requestFunction(messages []openai.ChatCompletionMessage, f openai.FunctionDefinition, conf *config.Config) (openai.ChatCompletionMessage, string, error) {
+	c := makeClient(conf)
+
+	ctx := context.Background()
+
+	req := openai.ChatCompletionRequest{
+		Model:    conf.Model,
+		Messages: messages,
+		Tools: []openai.Tool{
+			{
+				Type:     openai.ToolTypeFunction,
+				Function: f,
+			},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: openai.ToolTypeFunction,
+			Function: openai.ToolFunction{
+				Name: f.Name,
+			},
+		},
+	}
+
+	waitForRateLimit(req, conf)
+
+	resp, err := c.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return openai.ChatCompletionMessage{}, "", err
+	}
+
+	if len(resp.Choices) == 0 {
+		return openai.ChatCompletionMessage{}, "", errors.New("no choices returned")
+	}
+
+	message := resp.Choices[0].Message
+	if len(message.ToolCalls) == 0 {
+		return message, "", errors.New("the model did not call the function")
+	}
+	// every call in the message has to be answered when it is sent back to the
+	// model, so only the first one is kept
+	message.ToolCalls = message.ToolCalls[:1]
+
+	return message, message.ToolCalls[0].Function.Arguments, nil
+}
+
+// toolResult creates the message that answers a function call, used to send
+// validation errors back to the model.
+func toolResult(call openai.ChatCompletionMessage, content string) openai.ChatCompletionMessage {
+	msg := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleTool,
+		Content: content,
+	}
+	if len(call.ToolCalls) > 0 {
+		msg.ToolCallID = call.ToolCalls[0].ID
+	}
+	return msg
+}
@@ -0,0 +1,105 @@
+package apiverify
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+)
+
+var endpointHeadingRegex = regexp.MustCompile("^#{1,6}\\s+`?(GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS)\\s+(/[^\\s`]*)`?")
+var statusRegex = regexp.MustCompile(`\b([1-5]\d\d)\b`)
+var statusLineRegex = regexp.MustCompile(`(?i)status|respon|code|return|\b[1-5]\d\d\s+(?:OK|Created|Accepted|No Content|Bad|Unauthorized|Forbidden|Not Found|Conflict|Internal)`)
+
+// FromMarkdown reads the endpoints documented in markdown as written by otto apiDocs. Each
+// endpoint is a heading like "# GET /users/:id". The statuses are the ones mentioned in lines
+// about responses, and the shape of the response is the first JSON example after the word
+// response in the section.
+func FromMarkdown(contents string) *openapi.Document {
+	doc := openapi.New("API", "1.0.0")
+
+	var op *openapi.Operation
+	var inCode, inResponse, jsonBlock bool
+	var block []string
+	var firstStatus string
+	var shape *openapi.Schema
+
+	finish := func() {
+		if op == nil {
+			return
+		}
+		if len(op.Responses) == 0 {
+			// nothing documented, only check that the endpoint exists
+			op.Responses["2XX"] = &openapi.Response{Description: "Success"}
+			firstStatus = "2XX"
+		}
+		if shape != nil {
+			status := firstStatus
+			for key := range op.Responses {
+				if strings.HasPrefix(key, "2") && (status == "" || !strings.HasPrefix(status, "2") || key < status) {
+					status = key
+				}
+			}
+			op.Responses[status].Content = map[string]*openapi.MediaType{"application/json": {Schema: shape}}
+		}
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			if inCode {
+				if jsonBlock && inResponse && shape == nil && op != nil {
+					var value any
+					if json.Unmarshal([]byte(strings.Join(block, "\n")), &value) == nil {
+						shape = openapi.SchemaFromExample(value)
+					}
+				}
+				inCode, block = false, nil
+			} else {
+				inCode = true
+				lang := strings.TrimPrefix(trimmed, "```")
+				jsonBlock = lang == "" || strings.HasPrefix(lang, "json")
+			}
+			continue
+		}
+		if inCode {
+			block = append(block, line)
+			continue
+		}
+
+		if match := endpointHeadingRegex.FindStringSubmatch(line); match != nil {
+			finish()
+			method, path, err := openapi.ParseEndpoint(match[1] + " " + match[2])
+			if err != nil {
+				op = nil
+				continue
+			}
+			op = &openapi.Operation{Responses: map[string]*openapi.Response{}}
+			openapi.FillPathParameters(path, op)
+			doc.SetOperation(method, path, op)
+			inResponse, firstStatus, shape = false, "", nil
+			continue
+		}
+		if op == nil {
+			continue
+		}
+
+		if strings.Contains(strings.ToLower(line), "respon") {
+			inResponse = true
+		}
+		if statusLineRegex.MatchString(line) {
+			for _, match := range statusRegex.FindAllStringSubmatch(line, -1) {
+				if _, ok := op.Responses[match[1]]; !ok {
+					op.Responses[match[1]] = &openapi.Response{Description: strings.TrimSpace(line)}
+					if firstStatus == "" {
+						firstStatus = match[1]
+					}
+				}
+			}
+		}
+	}
+	finish()
+
+	return doc
+}
@@ -0,0 +1,217 @@
+package apiverify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+)
+
+// package for checking API documentation against a running service by making example requests
+
+// the most bytes of a response that are read
+const maxBodySize = 1 << 20
+
+// SafeMethods are the methods requested by default, as they shouldn't change anything
+var SafeMethods = []string{"get", "head"}
+
+// Options configures the requests
+type Options struct {
+	BaseURL string
+	// request every method, not just the safe ones
+	AllMethods bool
+	// added to every request, for example for authentication
+	Headers http.Header
+	Client  *http.Client
+}
+
+// Result is the outcome of requesting an endpoint
+type Result struct {
+	Method string
+	Path   string
+	URL    string
+	Status int
+	// why the endpoint wasn't requested, empty if it was
+	Skipped string
+	// the differences between the response and the documentation
+	Problems []string
+	// the request couldn't be made
+	Err error
+}
+
+// Endpoint is the method and path of the result
+func (r Result) Endpoint() string {
+	return strings.ToUpper(r.Method) + " " + r.Path
+}
+
+// Verify makes an example request to each operation in the document and compares the
+// responses to what is documented
+func Verify(doc *openapi.Document, opts Options) []Result {
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var results []Result
+	for _, path := range doc.SortedPaths() {
+		item := doc.Paths[path]
+		for _, method := range openapi.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			result := Result{Method: method, Path: path}
+			if !opts.AllMethods && !isSafe(method) {
+				result.Skipped = "not a safe method"
+				results = append(results, result)
+				continue
+			}
+			verifyOperation(doc, item, op, client, opts, &result)
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func isSafe(method string) bool {
+	for _, m := range SafeMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildRequest creates the example request for the operation on the path
+func BuildRequest(doc *openapi.Document, baseURL, method, path string, item *openapi.PathItem, op *openapi.Operation) (*http.Request, error) {
+	query := url.Values{}
+	headers := http.Header{}
+	for _, param := range append(append([]*openapi.Parameter{}, item.Parameters...), op.Parameters...) {
+		if param == nil {
+			continue
+		}
+		value := doc.ParameterExample(param)
+		switch param.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(value))
+		case "query":
+			if param.Required {
+				query.Set(param.Name, value)
+			}
+		case "header":
+			if param.Required {
+				headers.Set(param.Name, value)
+			}
+		}
+	}
+
+	target := strings.TrimSuffix(baseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content["application/json"]; ok && media != nil {
+			example := media.Example
+			if example == nil {
+				example = doc.Example(media.Schema)
+			}
+			contents, err := json.Marshal(example)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(contents)
+			headers.Set("Content-Type", "application/json")
+		}
+	}
+
+	req, err := http.NewRequest(strings.ToUpper(method), target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	return req, nil
+}
+
+func verifyOperation(doc *openapi.Document, item *openapi.PathItem, op *openapi.Operation, client *http.Client, opts Options, result *Result) {
+	req, err := BuildRequest(doc, opts.BaseURL, result.Method, result.Path, item, op)
+	if err != nil {
+		result.Err = err
+		return
+	}
+	for name, values := range opts.Headers {
+		req.Header[name] = values
+	}
+	result.URL = req.URL.String()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return
+	}
+	defer resp.Body.Close()
+	result.Status = resp.StatusCode
+
+	status, response := documentedResponse(op, resp.StatusCode)
+	if response == nil {
+		result.Problems = append(result.Problems, fmt.Sprintf("status %d is not documented, the documented statuses are %s", resp.StatusCode, strings.Join(statuses(op), ", ")))
+		return
+	}
+	if result.Method == "head" || len(response.Content) == 0 {
+		return
+	}
+
+	media, ok := response.Content["application/json"]
+	if !ok || media == nil || media.Schema == nil {
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.Contains(contentType, "json") {
+		result.Problems = append(result.Problems, fmt.Sprintf("the %s response is documented as application/json, but is %s", status, resp.Header.Get("Content-Type")))
+		return
+	}
+
+	contents, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		result.Err = err
+		return
+	}
+	var value any
+	err = json.Unmarshal(contents, &value)
+	if err != nil {
+		result.Problems = append(result.Problems, fmt.Sprintf("the %s response is not valid JSON: %s", status, err))
+		return
+	}
+	result.Problems = append(result.Problems, doc.CheckValue(media.Schema, value, "body")...)
+}
+
+// documentedResponse returns the response documented for the status, matching exact statuses
+// first, then ranges like 2XX, then the default
+func documentedResponse(op *openapi.Operation, status int) (string, *openapi.Response) {
+	for _, key := range []string{strconv.Itoa(status), fmt.Sprintf("%dXX", status/100), "default"} {
+		if response, ok := op.Responses[key]; ok && response != nil {
+			return key, response
+		}
+	}
+	return "", nil
+}
+
+func statuses(op *openapi.Operation) []string {
+	var out []string
+	for status := range op.Responses {
+		out = append(out, status)
+	}
+	sort.Strings(out)
+	return out
+}
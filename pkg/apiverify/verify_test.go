@@ -0,0 +1,104 @@
+package apiverify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+)
+
+const spec = `openapi: 3.1.0
+info:
+  title: Users
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: The user
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id, name]
+                properties:
+                  id:
+                    type: integer
+                  name:
+                    type: string
+    delete:
+      responses:
+        "204":
+          description: Deleted
+  /health:
+    get:
+      responses:
+        "200":
+          description: OK
+`
+
+func TestVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/1":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": "1", "email": "a@example.com"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	doc, err := openapi.Parse([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := Verify(doc, Options{BaseURL: server.URL})
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	byEndpoint := map[string]Result{}
+	for _, result := range results {
+		byEndpoint[result.Endpoint()] = result
+	}
+
+	if !strings.Contains(strings.Join(byEndpoint["GET /health"].Problems, "\n"), "status 404") {
+		t.Errorf("expected an undocumented status, got %v", byEndpoint["GET /health"].Problems)
+	}
+	if byEndpoint["DELETE /users/{id}"].Skipped == "" {
+		t.Error("expected DELETE to be skipped")
+	}
+	problems := strings.Join(byEndpoint["GET /users/{id}"].Problems, "\n")
+	for _, want := range []string{"body.id", "body.name", "body.email"} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("expected a problem with %s, got %s", want, problems)
+		}
+	}
+}
+
+func TestFromMarkdown(t *testing.T) {
+	markdown := "# GET /users/:id\n\nGets a user.\n\n## Response\n\nReturns 200 OK, or 404 if the user doesn't exist.\n\n```json\n{\"id\": 1, \"name\": \"Otto\"}\n```\n"
+	doc := FromMarkdown(markdown)
+
+	op := doc.Paths["/users/{id}"].Operation("get")
+	if op == nil {
+		t.Fatal("expected GET /users/{id}")
+	}
+	if op.Responses["200"] == nil || op.Responses["404"] == nil {
+		t.Fatalf("expected 200 and 404 responses, got %v", op.Responses)
+	}
+	schema := op.Responses["200"].Content["application/json"].Schema
+	if schema.Properties["name"] == nil {
+		t.Errorf("expected the response shape from the example, got %+v", schema)
+	}
+}
@@ -0,0 +1,120 @@
+package authored
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/state"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/symbols"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/textfile"
+)
+
+// package for keeping track of the comments Otto writes into files, so
+// they can be refreshed or removed later without touching human written ones
+
+const fileName = "comments.json"
+
+// Comment is a doc comment Otto wrote into a file
+type Comment struct {
+	Symbol string `json:"symbol"`
+	// Hash of the comment lines. If a human edits the comment, the hash
+	// no longer matches and the comment is treated as human written.
+	Hash string `json:"hash"`
+}
+
+// Comments maps file paths relative to the repository root to the comments Otto wrote into them
+type Comments map[string][]Comment
+
+// Load loads the comments recorded for the repository at root
+func Load(root string) (Comments, error) {
+	comments := Comments{}
+	err := state.Load(root, fileName, &comments)
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// Save saves the recorded comments for the repository at root
+func (c Comments) Save(root string) error {
+	return state.Save(root, fileName, c)
+}
+
+// Hash hashes the comment lines, ignoring indentation
+func Hash(lines []string) string {
+	var trimmed []string
+	for _, line := range lines {
+		trimmed = append(trimmed, strings.TrimSpace(line))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(trimmed, "\n")))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// NewComment creates a record for the comment lines written above or inside symbol
+func NewComment(symbol string, lines []string) Comment {
+	return Comment{
+		Symbol: symbol,
+		Hash:   Hash(lines),
+	}
+}
+
+// Add records comments written into the file
+func (c Comments) Add(path string, comments []Comment) {
+	if len(comments) == 0 {
+		return
+	}
+	c[path] = append(c[path], comments...)
+}
+
+// Strip removes the comments recorded for the file from its contents and forgets them.
+// If only is not nil, just the comments of the symbols with those keys are removed.
+// Returns the new contents and the number of comments removed.
+func (c Comments) Strip(path, contents string, only map[string]bool) (string, int, error) {
+	records, ok := c[path]
+	if !ok {
+		return contents, 0, nil
+	}
+
+	hashes := map[string]bool{}
+	for _, record := range records {
+		hashes[record.Hash] = true
+	}
+
+	syms, err := symbols.Parse(path, contents)
+	if err != nil {
+		return "", 0, err
+	}
+
+	lines := strings.Split(contents, "\n")
+	var edits []textfile.Edit
+	for _, sym := range syms {
+		if only != nil && !only[sym.Key()] {
+			continue
+		}
+		ranges := [][2]int{{sym.DocStart, sym.DocEnd}, {sym.DocstringStart, sym.DocstringEnd}}
+		for _, r := range ranges {
+			if r[0] == 0 || !hashes[Hash(lines[r[0]-1:r[1]])] {
+				continue
+			}
+			edits = append(edits, textfile.Edit{Start: r[0], End: r[1]})
+		}
+	}
+
+	newContents, err := textfile.ApplyEdits(contents, edits)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// keep the records of the comments that were left alone
+	var kept []Comment
+	for _, record := range records {
+		if only != nil && !only[record.Symbol] {
+			kept = append(kept, record)
+		}
+	}
+	delete(c, path)
+	c.Add(path, kept)
+
+	return newContents, len(edits), nil
+}
@@ -0,0 +1,48 @@
+package authored
+
+import (
+	"testing"
+)
+
+func TestStrip(t *testing.T) {
+	code := `package example
+
+// Load loads things.
+// Written by Otto.
+func Load() {}
+
+// Save was written by a human.
+func Save() {}
+`
+
+	comments := Comments{}
+	comments.Add("example.go", []Comment{
+		NewComment("Load", []string{"// Load loads things.", "// Written by Otto."}),
+		NewComment("Gone", []string{"// Gone was deleted."}),
+	})
+
+	result, removed, err := comments.Strip("example.go", code, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `package example
+
+func Load() {}
+
+// Save was written by a human.
+func Save() {}
+`
+
+	if removed != 1 {
+		t.Errorf("Expected 1 comment removed, but got %d", removed)
+	}
+
+	if result != expected {
+		t.Errorf("Expected result: %q, but got: %q", expected, result)
+	}
+
+	if _, ok := comments["example.go"]; ok {
+		t.Errorf("Expected the comments for example.go to be forgotten")
+	}
+}
@@ -42,12 +42,13 @@ func GetMaxTokens(model string) int {
 	if strings.Contains(model, "32k") {
 		return 32768
 	}
-	if strings.Contains(model, "4") {
-		return 8192
-	}
+	// checked before the other GPT-4 models, which it would match
 	if strings.Contains(model, "4-turbo") {
 		return 128000
 	}
+	if strings.Contains(model, "4") {
+		return 8192
+	}
 
 	return 4096
 }
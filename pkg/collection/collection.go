@@ -0,0 +1,180 @@
+package collection
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+)
+
+// package for exporting API documentation as request collections for API clients, a Postman
+// collection or a .http file for the REST Client extension of VS Code and JetBrains IDEs
+
+// the variables requests are written with, so they can be set per environment
+const (
+	BaseURLVariable = "baseUrl"
+	TokenVariable   = "token"
+	APIKeyVariable  = "apiKey"
+)
+
+// DefaultBaseURL is used when the document has no servers
+const DefaultBaseURL = "http://localhost:8080"
+
+// headers that carry credentials, by their lowercase name, and what they are set to
+var authHeaders = map[string]string{
+	"authorization": "Bearer {{" + TokenVariable + "}}",
+	"x-api-key":     "{{" + APIKeyVariable + "}}",
+	"api-key":       "{{" + APIKeyVariable + "}}",
+	"apikey":        "{{" + APIKeyVariable + "}}",
+}
+
+// Request is an example request for an operation
+type Request struct {
+	Name        string
+	Description string
+	// the first tag of the operation, used to group requests
+	Folder string
+	Method string
+	// the path with its parameters in the form :name
+	Path string
+	// example values of the path parameters, in the order they appear
+	PathParams []Param
+	Query      []Param
+	Headers    []Param
+	// the example JSON body, empty if there is none
+	Body string
+}
+
+// Param is a parameter with an example value
+type Param struct {
+	Name        string
+	Value       string
+	Description string
+	// optional query parameters are included, but disabled
+	Disabled bool
+}
+
+// BaseURL returns the base URL of the API, the first server of the document if it has one
+func BaseURL(doc *openapi.Document) string {
+	if len(doc.Servers) > 0 && doc.Servers[0].URL != "" {
+		return doc.Servers[0].URL
+	}
+	return DefaultBaseURL
+}
+
+// Requests returns an example request for each operation in the document
+func Requests(doc *openapi.Document) ([]Request, error) {
+	var requests []Request
+	for _, path := range doc.SortedPaths() {
+		item := doc.Paths[path]
+		for _, method := range openapi.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			request, err := newRequest(doc, method, path, item, op)
+			if err != nil {
+				return nil, fmt.Errorf("error creating the request for %s %s: %s", strings.ToUpper(method), path, err)
+			}
+			requests = append(requests, request)
+		}
+	}
+	return requests, nil
+}
+
+func newRequest(doc *openapi.Document, method, path string, item *openapi.PathItem, op *openapi.Operation) (Request, error) {
+	request := Request{
+		Name:        op.Summary,
+		Description: op.Description,
+		Method:      strings.ToUpper(method),
+		Path:        path,
+	}
+	if request.Name == "" {
+		request.Name = request.Method + " " + path
+	}
+	if len(op.Tags) > 0 {
+		request.Folder = op.Tags[0]
+	}
+
+	hasAuth := false
+	for _, param := range append(append([]*openapi.Parameter{}, item.Parameters...), op.Parameters...) {
+		if param == nil {
+			continue
+		}
+		value := doc.ParameterExample(param)
+		switch param.In {
+		case "path":
+			request.Path = strings.ReplaceAll(request.Path, "{"+param.Name+"}", ":"+param.Name)
+			request.PathParams = append(request.PathParams, Param{Name: param.Name, Value: value, Description: param.Description})
+		case "query":
+			request.Query = append(request.Query, Param{Name: param.Name, Value: value, Description: param.Description, Disabled: !param.Required})
+		case "header":
+			if auth, ok := authHeaders[strings.ToLower(param.Name)]; ok {
+				value = auth
+				hasAuth = true
+			}
+			request.Headers = append(request.Headers, Param{Name: param.Name, Value: value, Description: param.Description})
+		}
+	}
+	if !hasAuth {
+		request.Headers = append(request.Headers, Param{Name: "Authorization", Value: authHeaders["authorization"]})
+	}
+
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content["application/json"]; ok && media != nil {
+			example := media.Example
+			if example == nil {
+				example = doc.Example(media.Schema)
+			}
+			body, err := json.MarshalIndent(example, "", "  ")
+			if err != nil {
+				return Request{}, err
+			}
+			request.Body = string(body)
+			request.Headers = append(request.Headers, Param{Name: "Content-Type", Value: "application/json"})
+		}
+	}
+
+	return request, nil
+}
+
+// URL returns the URL of the request, with the base URL as a variable and the example values
+// of the path parameters filled in
+func (r Request) URL() string {
+	// only whole segments are replaced, so :id doesn't change :idx
+	segments := strings.Split(r.Path, "/")
+	for i, segment := range segments {
+		for _, param := range r.PathParams {
+			if segment == ":"+param.Name {
+				segments[i] = url.PathEscape(param.Value)
+				break
+			}
+		}
+	}
+	path := strings.Join(segments, "/")
+	query := url.Values{}
+	for _, param := range r.Query {
+		if !param.Disabled {
+			query.Add(param.Name, param.Value)
+		}
+	}
+	target := "{{" + BaseURLVariable + "}}" + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target
+}
+
+// usesAPIKey reports whether any of the requests send an API key
+func usesAPIKey(requests []Request) bool {
+	for _, request := range requests {
+		for _, header := range request.Headers {
+			if strings.Contains(header.Value, "{{"+APIKeyVariable+"}}") {
+				return true
+			}
+		}
+	}
+	return false
+}
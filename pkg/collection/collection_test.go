@@ -0,0 +1,104 @@
+package collection
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+)
+
+const spec = `openapi: 3.1.0
+info:
+  title: Users
+  version: 1.0.0
+paths:
+  /users:
+    post:
+      summary: Create a user
+      tags: [users]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                email:
+                  type: string
+                  format: email
+      responses:
+        "201":
+          description: Created
+  /users/{id}:
+    get:
+      summary: Get a user
+      tags: [users]
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+            example: 42
+        - name: fields
+          in: query
+          schema:
+            type: string
+      responses:
+        "200":
+          description: The user
+`
+
+func load(t *testing.T) *openapi.Document {
+	doc, err := openapi.Parse([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestHTTPFile(t *testing.T) {
+	out, err := HTTPFile(load(t), DefaultBaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"@baseUrl = http://localhost:8080",
+		"### Create a user\nPOST {{baseUrl}}/users\nAuthorization: Bearer {{token}}\nContent-Type: application/json\n\n{\n  \"email\": \"user@example.com\"\n}",
+		"# optional query parameter: fields=string\nGET {{baseUrl}}/users/42\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	request := Request{Path: "/a/:id/:idx", PathParams: []Param{{Name: "id", Value: "1"}, {Name: "idx", Value: "a b"}}}
+	if got := request.URL(); got != "{{baseUrl}}/a/1/a%20b" {
+		t.Errorf("unexpected URL %s", got)
+	}
+}
+
+func TestPostman(t *testing.T) {
+	out, err := Postman(load(t), DefaultBaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var collection postmanCollection
+	err = json.Unmarshal([]byte(out), &collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Item) != 1 || collection.Item[0].Name != "users" || len(collection.Item[0].Item) != 2 {
+		t.Fatalf("expected a users folder with 2 requests, got %+v", collection.Item)
+	}
+	get := collection.Item[0].Item[1].Request
+	if get.URL.Raw != "{{baseUrl}}/users/:id" || len(get.URL.Variable) != 1 || get.URL.Variable[0].Value != "42" {
+		t.Errorf("expected the path variable id=42, got %+v", get.URL)
+	}
+	if len(get.URL.Query) != 1 || !get.URL.Query[0].Disabled {
+		t.Errorf("expected a disabled optional query parameter, got %+v", get.URL.Query)
+	}
+}
@@ -0,0 +1,48 @@
+package collection
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+)
+
+// HTTPFile writes the document as a .http file, with the base URL and credentials as file
+// variables that can be overridden by the environments of the editor
+func HTTPFile(doc *openapi.Document, baseURL string) (string, error) {
+	requests, err := Requests(doc)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", doc.Info.Title)
+	fmt.Fprintf(&b, "@%s = %s\n", BaseURLVariable, baseURL)
+	fmt.Fprintf(&b, "@%s = \n", TokenVariable)
+	if usesAPIKey(requests) {
+		fmt.Fprintf(&b, "@%s = \n", APIKeyVariable)
+	}
+
+	for _, request := range requests {
+		fmt.Fprintf(&b, "\n### %s\n", request.Name)
+		for _, line := range strings.Split(strings.TrimSpace(request.Description), "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+		}
+		for _, param := range request.Query {
+			if param.Disabled {
+				fmt.Fprintf(&b, "# optional query parameter: %s=%s\n", param.Name, param.Value)
+			}
+		}
+		fmt.Fprintf(&b, "%s %s\n", request.Method, request.URL())
+		for _, header := range request.Headers {
+			fmt.Fprintf(&b, "%s: %s\n", header.Name, header.Value)
+		}
+		if request.Body != "" {
+			fmt.Fprintf(&b, "\n%s\n", request.Body)
+		}
+	}
+
+	return b.String(), nil
+}
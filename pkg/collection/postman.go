@@ -0,0 +1,161 @@
+package collection
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/openapi"
+)
+
+// PostmanSchema is the schema of the collections written
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Auth     *postmanAuth      `json:"auth,omitempty"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// an item is either a request or a folder of items
+type postmanItem struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Request     *postmanRequest `json:"request,omitempty"`
+	Item        []postmanItem   `json:"item,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanVariable `json:"header"`
+	URL    postmanURL        `json:"url"`
+	Body   *postmanBody      `json:"body,omitempty"`
+	// requests without their own auth inherit the auth of the collection
+	Auth *postmanAuth `json:"auth,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path,omitempty"`
+	Query    []postmanVariable `json:"query,omitempty"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string         `json:"mode"`
+	Raw     string         `json:"raw"`
+	Options map[string]any `json:"options,omitempty"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanVariable `json:"bearer,omitempty"`
+}
+
+type postmanVariable struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+// Postman writes the document as a Postman v2.1 collection. The base URL and credentials are
+// collection variables, and requests are grouped into folders by their first tag.
+func Postman(doc *openapi.Document, baseURL string) (string, error) {
+	requests, err := Requests(doc)
+	if err != nil {
+		return "", err
+	}
+
+	collection := postmanCollection{
+		Info: postmanInfo{Name: doc.Info.Title, Description: doc.Info.Description, Schema: PostmanSchema},
+		Auth: &postmanAuth{
+			Type:   "bearer",
+			Bearer: []postmanVariable{{Key: "token", Value: "{{" + TokenVariable + "}}", Type: "string"}},
+		},
+		Variable: []postmanVariable{
+			{Key: BaseURLVariable, Value: baseURL, Type: "string"},
+			{Key: TokenVariable, Value: "", Type: "string"},
+		},
+	}
+
+	folders := map[string]*postmanItem{}
+	for _, request := range requests {
+		item := postmanItemFor(request)
+		if request.Folder == "" {
+			collection.Item = append(collection.Item, item)
+			continue
+		}
+		folder, ok := folders[request.Folder]
+		if !ok {
+			folder = &postmanItem{Name: request.Folder}
+			folders[request.Folder] = folder
+		}
+		folder.Item = append(folder.Item, item)
+	}
+	if usesAPIKey(requests) {
+		collection.Variable = append(collection.Variable, postmanVariable{Key: APIKeyVariable, Value: "", Type: "string"})
+	}
+
+	var names []string
+	for name := range folders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var items []postmanItem
+	for _, name := range names {
+		items = append(items, *folders[name])
+	}
+	collection.Item = append(items, collection.Item...)
+
+	out, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
+
+func postmanItemFor(request Request) postmanItem {
+	// the raw URL keeps the path variables, Postman fills them in from their values
+	url := postmanURL{
+		Raw:  "{{" + BaseURLVariable + "}}" + request.Path,
+		Host: []string{"{{" + BaseURLVariable + "}}"},
+		Path: strings.Split(strings.Trim(request.Path, "/"), "/"),
+	}
+	if _, query, ok := strings.Cut(request.URL(), "?"); ok {
+		url.Raw += "?" + query
+	}
+	for _, param := range request.Query {
+		url.Query = append(url.Query, postmanVariable{Key: param.Name, Value: param.Value, Description: param.Description, Disabled: param.Disabled})
+	}
+	for _, param := range request.PathParams {
+		url.Variable = append(url.Variable, postmanVariable{Key: param.Name, Value: param.Value, Description: param.Description})
+	}
+
+	req := &postmanRequest{Method: request.Method, URL: url, Header: []postmanVariable{}}
+	for _, header := range request.Headers {
+		// the bearer token comes from the auth of the collection
+		if strings.EqualFold(header.Name, "Authorization") && header.Value == authHeaders["authorization"] {
+			continue
+		}
+		req.Header = append(req.Header, postmanVariable{Key: header.Name, Value: header.Value, Description: header.Description})
+	}
+	if request.Body != "" {
+		req.Body = &postmanBody{
+			Mode:    "raw",
+			Raw:     request.Body,
+			Options: map[string]any{"raw": map[string]string{"language": "json"}},
+		}
+	}
+
+	return postmanItem{Name: request.Name, Description: request.Description, Request: req}
+}
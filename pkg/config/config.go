@@ -17,6 +17,15 @@ type Config struct {
 	UserColor string `json:"user_color"`
 	OttoColor string `json:"otto_color"`
 	BaseURL   string `json:"base_url"`
+	// Maps language names to the doc comment style to use for them
+	DocStyles map[string]string `json:"doc_styles,omitempty"`
+	// Rate limits shared by all requests. 0 means unlimited.
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	TokensPerMinute   int `json:"tokens_per_minute,omitempty"`
+	// Number of files to document at the same time
+	Concurrency int `json:"concurrency,omitempty"`
+	// Maps file extensions to the commands that format the files otto edit writes
+	Formatters map[string]string `json:"formatters,omitempty"`
 }
 
 // also returns the path to the config file
@@ -9,7 +9,10 @@ var CommentOperators = map[string]string{
 	".cs":    "//",   // C#
 	".java":  "//",   // Java
 	".js":    "//",   // JavaScript
+	".jsx":   "//",   // JavaScript (JSX)
+	".mjs":   "//",   // JavaScript (ES Module)
 	".ts":    "//",   // TypeScript
+	".tsx":   "//",   // TypeScript (JSX)
 	".php":   "//",   // PHP
 	".rb":    "#",    // Ruby
 	".rs":    "//",   // Rust
@@ -20,7 +23,8 @@ var CommentOperators = map[string]string{
 	".m":     "%",    // MATLAB
 	".r":     "#",    // R
 	".scala": "//",   // Scala
-	".kts":   "//",   // Kotlin
+	".kt":    "//",   // Kotlin
+	".kts":   "//",   // Kotlin Script
 	".vb":    "'",    // Visual Basic .NET
 	".f":     "!",    // Fortran
 	".asm":   ";",    // Assembly
@@ -0,0 +1,159 @@
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/constants"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/symbols"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+// package for measuring how many exported symbols have doc comments
+
+// Package is the coverage of a directory
+type Package struct {
+	Path       string  `json:"package"`
+	Documented int     `json:"documented"`
+	Total      int     `json:"total"`
+	Percent    float64 `json:"percent"`
+	// the undocumented symbols in the form path:line symbol
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Report is the coverage of a repository
+type Report struct {
+	Packages   []Package `json:"packages"`
+	Documented int       `json:"documented"`
+	Total      int       `json:"total"`
+	Percent    float64   `json:"percent"`
+}
+
+// Supported reports whether the coverage of the file can be measured
+func Supported(filePath string) bool {
+	_, ok := constants.CommentOperators[strings.ToLower(filepath.Ext(filePath))]
+	return ok
+}
+
+// Build measures the coverage of the files. Test files and files that can't be parsed are skipped.
+func Build(files []prompt.GitFile) Report {
+	packages := map[string]*Package{}
+	sorted := append([]prompt.GitFile{}, files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	for _, file := range sorted {
+		filePath := path.Clean(filepath.ToSlash(file.Path))
+		if !Supported(filePath) || utils.IsTestFile(filePath) {
+			continue
+		}
+		syms, err := symbols.Parse(filePath, file.Contents)
+		if err != nil {
+			continue
+		}
+
+		dir := path.Dir(filePath)
+		for _, sym := range symbols.Exported(syms) {
+			pkg, ok := packages[dir]
+			if !ok {
+				pkg = &Package{Path: dir}
+				packages[dir] = pkg
+			}
+			pkg.Total++
+			// godoc uses the comment on a group for the declarations in it
+			if sym.HasDoc() || sym.GroupDoc {
+				pkg.Documented++
+			} else {
+				pkg.Missing = append(pkg.Missing, fmt.Sprintf("%s:%d %s", filePath, sym.Line, sym.Key()))
+			}
+		}
+	}
+
+	var report Report
+	for _, pkg := range packages {
+		pkg.Percent = percent(pkg.Documented, pkg.Total)
+		report.Packages = append(report.Packages, *pkg)
+		report.Documented += pkg.Documented
+		report.Total += pkg.Total
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		return report.Packages[i].Path < report.Packages[j].Path
+	})
+	report.Percent = percent(report.Documented, report.Total)
+
+	return report
+}
+
+// percent rounded to one decimal place. Nothing to document counts as fully documented.
+func percent(documented, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return math.Round(float64(documented)/float64(total)*1000) / 10
+}
+
+// Text renders the report as a table. If missing is true the undocumented symbols are listed.
+func (r Report) Text(missing bool) string {
+	width := len("TOTAL")
+	for _, pkg := range r.Packages {
+		if len(pkg.Path) > width {
+			width = len(pkg.Path)
+		}
+	}
+
+	var b strings.Builder
+	for _, pkg := range r.Packages {
+		fmt.Fprintf(&b, "%-*s  %4d/%-4d  %5.1f%%\n", width, pkg.Path, pkg.Documented, pkg.Total, pkg.Percent)
+		if missing {
+			for _, symbol := range pkg.Missing {
+				fmt.Fprintf(&b, "    %s\n", symbol)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%-*s  %4d/%-4d  %5.1f%%\n", width, "TOTAL", r.Documented, r.Total, r.Percent)
+	return b.String()
+}
+
+// JSON renders the report as JSON
+func (r Report) JSON() (string, error) {
+	contents, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(contents) + "\n", nil
+}
+
+// Badge renders the total as a shields.io endpoint badge, see https://shields.io/badges/endpoint-badge
+func (r Report) Badge() (string, error) {
+	color := "red"
+	switch {
+	case r.Percent >= 90:
+		color = "brightgreen"
+	case r.Percent >= 75:
+		color = "green"
+	case r.Percent >= 60:
+		color = "yellow"
+	case r.Percent >= 40:
+		color = "orange"
+	}
+
+	badge := struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Label         string `json:"label"`
+		Message       string `json:"message"`
+		Color         string `json:"color"`
+	}{1, "docs", fmt.Sprintf("%.0f%%", math.Floor(r.Percent)), color}
+
+	contents, err := json.Marshal(badge)
+	if err != nil {
+		return "", err
+	}
+	return string(contents) + "\n", nil
+}
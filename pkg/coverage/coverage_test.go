@@ -0,0 +1,45 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+func TestBuild(t *testing.T) {
+	files := []prompt.GitFile{
+		{Path: "pkg/b/b.py", Contents: "def run():\n    \"\"\"Runs it.\"\"\"\n    pass\n"},
+		{Path: "pkg/a/a.go", Contents: "package a\n\n// Documented is documented.\nfunc Documented() {}\n\nfunc Missing() {}\n\nfunc unexported() {}\n"},
+		{Path: "README.md", Contents: "# Not code\n"},
+		{Path: "pkg/a/a_test.go", Contents: "package a\n\nfunc TestMissing(t *testing.T) {}\n"},
+		{Path: "pkg/b/test_b.py", Contents: "def test_run():\n    pass\n"},
+		{Path: "web/app.test.js", Contents: "export function helper() {}\n"},
+		{Path: "pkg/c/c.go", Contents: "package c\n\n// Levels of logging.\nconst (\n\tDebug = iota\n\tInfo\n)\n"},
+	}
+
+	report := Build(files)
+
+	if report.Total != 5 || report.Documented != 4 || report.Percent != 80 {
+		t.Errorf("Expected 4/5 documented at 80%%, but got %d/%d at %.1f%%", report.Documented, report.Total, report.Percent)
+	}
+
+	if len(report.Packages) != 3 || report.Packages[0].Path != "pkg/a" || report.Packages[0].Percent != 50 {
+		t.Fatalf("Unexpected packages: %+v", report.Packages)
+	}
+	if len(report.Packages[0].Missing) != 1 || report.Packages[0].Missing[0] != "pkg/a/a.go:6 Missing" {
+		t.Errorf("Unexpected missing symbols: %v", report.Packages[0].Missing)
+	}
+
+	if !strings.Contains(report.Text(true), "    pkg/a/a.go:6 Missing\n") {
+		t.Errorf("Expected the text report to list missing symbols, but got:\n%s", report.Text(true))
+	}
+
+	badge, err := report.Badge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if badge != `{"schemaVersion":1,"label":"docs","message":"80%","color":"green"}`+"\n" {
+		t.Errorf("Unexpected badge: %s", badge)
+	}
+}
@@ -0,0 +1,180 @@
+package depgraph
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// the most modules drawn in a diagram before they are grouped by their parent directories
+const maxDiagramNodes = 40
+
+// Mermaid draws the dependencies between the modules as a Mermaid flowchart. Modules are
+// grouped by their parent directories until there are at most maxNodes of them.
+func (g *Graph) Mermaid(maxNodes int) string {
+	depth := 0
+	for _, p := range g.Paths() {
+		if d := strings.Count(p, "/") + 1; d > depth {
+			depth = d
+		}
+	}
+
+	var nodes []string
+	var edges map[[2]string]bool
+	for ; depth > 0; depth-- {
+		nodes, edges = g.collapse(depth)
+		if len(nodes) <= maxNodes {
+			break
+		}
+	}
+
+	ids := map[string]string{}
+	for i, node := range nodes {
+		ids[node] = fmt.Sprintf("m%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for _, node := range nodes {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node], strings.ReplaceAll(node, "\"", "#quot;"))
+	}
+
+	var sorted [][2]string
+	for edge := range edges {
+		sorted = append(sorted, edge)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i][0] != sorted[j][0] {
+			return sorted[i][0] < sorted[j][0]
+		}
+		return sorted[i][1] < sorted[j][1]
+	})
+	for _, edge := range sorted {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[edge[0]], ids[edge[1]])
+	}
+
+	return b.String()
+}
+
+// collapse groups the modules by the first depth elements of their paths, keeping
+// the ones that have files or dependencies
+func (g *Graph) collapse(depth int) ([]string, map[[2]string]bool) {
+	group := func(p string) string {
+		parts := strings.Split(p, "/")
+		if len(parts) > depth {
+			parts = parts[:depth]
+		}
+		return strings.Join(parts, "/")
+	}
+
+	set := map[string]bool{}
+	edges := map[[2]string]bool{}
+	for _, p := range g.Paths() {
+		m := g.Modules[p]
+		if len(m.Files) > 0 || len(m.Imports) > 0 {
+			set[group(p)] = true
+		}
+		for _, imp := range m.Imports {
+			from, to := group(p), group(imp)
+			if from != to {
+				edges[[2]string{from, to}] = true
+				set[to] = true
+			}
+		}
+	}
+
+	return sortedKeys(set), edges
+}
+
+// the anchor of the section for the module in the architecture document
+func anchor(modulePath string) string {
+	if modulePath == "." {
+		return "module-root"
+	}
+	return "module-" + strings.NewReplacer("/", "-", ".", "-").Replace(modulePath)
+}
+
+// the first sentence of a summary, used in the module map
+func firstSentence(summary string) string {
+	summary = strings.TrimSpace(strings.Split(strings.TrimSpace(summary), "\n")[0])
+	if i := strings.Index(summary, ". "); i >= 0 {
+		return summary[:i+1]
+	}
+	return summary
+}
+
+// Architecture renders the architecture document: the overview, a map of the modules, a diagram of
+// their dependencies and a section for each module with its summary. name is used for the root module.
+func (g *Graph) Architecture(name, overview string, summaries map[string]string) string {
+	label := func(p string) string {
+		if p == "." {
+			return name
+		}
+		return p
+	}
+	links := func(paths []string) string {
+		var out []string
+		for _, p := range paths {
+			out = append(out, fmt.Sprintf("[`%s`](#%s)", label(p), anchor(p)))
+		}
+		return strings.Join(out, ", ")
+	}
+
+	var b strings.Builder
+	b.WriteString("# Architecture\n\n")
+	if overview != "" {
+		b.WriteString(strings.TrimSpace(overview) + "\n\n")
+	}
+
+	b.WriteString("## Module map\n\n")
+	var list func(p string, depth int)
+	list = func(p string, depth int) {
+		fmt.Fprintf(&b, "%s- [`%s`](#%s)", strings.Repeat("  ", depth), label(p), anchor(p))
+		if summary := firstSentence(summaries[p]); summary != "" {
+			b.WriteString(" - " + summary)
+		}
+		b.WriteString("\n")
+		for _, child := range g.Modules[p].Children {
+			list(child, depth+1)
+		}
+	}
+	list(".", 0)
+	b.WriteString("\n")
+
+	b.WriteString("## Dependencies\n\n")
+	b.WriteString("Arrows point from a module to the modules it imports.\n\n")
+	b.WriteString("```mermaid\n" + g.Mermaid(maxDiagramNodes) + "```\n\n")
+
+	b.WriteString("## Modules\n\n")
+	for _, p := range g.Paths() {
+		m := g.Modules[p]
+		fmt.Fprintf(&b, "<a id=\"%s\"></a>\n\n### `%s`\n\n", anchor(p), label(p))
+		if summary := strings.TrimSpace(summaries[p]); summary != "" {
+			b.WriteString(summary + "\n\n")
+		}
+
+		var files []string
+		for _, file := range m.Files {
+			files = append(files, "`"+path.Base(file)+"`")
+		}
+		details := []struct {
+			name  string
+			value string
+		}{
+			{"Files", strings.Join(files, ", ")},
+			{"Submodules", links(m.Children)},
+			{"Depends on", links(m.Imports)},
+			{"Used by", links(g.Dependents(p))},
+			{"External dependencies", "`" + strings.Join(m.External, "`, `") + "`"},
+		}
+		for _, detail := range details {
+			if detail.value != "" && detail.value != "``" {
+				fmt.Fprintf(&b, "- **%s:** %s\n", detail.name, detail.value)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
@@ -0,0 +1,280 @@
+package depgraph
+
+import (
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+// package for building a graph of the dependencies between the directories of a repository
+
+// Module is a directory of the repository
+type Module struct {
+	// slash separated path relative to the repository root, "." for the root
+	Path string
+	// paths of the files directly in the directory
+	Files []string
+	// the modules of the repository this one imports
+	Imports []string
+	// imports from outside the repository, not including the Go standard library
+	External []string
+	// the modules in the directories directly below this one
+	Children []string
+}
+
+// Graph is the module dependency graph of a repository
+type Graph struct {
+	Modules map[string]*Module
+}
+
+var goModRegex = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+var jsImportRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^\s*(?:import|export)\s[^'"]*?\bfrom\s*['"]([^'"]+)['"]`),
+	regexp.MustCompile(`(?m)^\s*import\s*['"]([^'"]+)['"]`),
+	regexp.MustCompile(`\b(?:require|import)\s*\(\s*['"]([^'"]+)['"]\s*\)`),
+}
+
+var pyFromRegex = regexp.MustCompile(`(?m)^\s*from\s+(\.*)([\w.]*)\s+import\b`)
+var pyImportRegex = regexp.MustCompile(`(?m)^\s*import\s+([\w.]+(?:\s*,\s*[\w.]+)*)`)
+
+var jsExtensions = []string{".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs"}
+
+// Build builds the graph from the files of the repository. Go imports are parsed, JavaScript,
+// TypeScript and Python imports are found with regular expressions.
+func Build(files []prompt.GitFile) *Graph {
+	g := &Graph{Modules: map[string]*Module{}}
+
+	paths := map[string]bool{}
+	// Go module paths by the directory of their go.mod
+	goModules := map[string]string{}
+	for _, file := range files {
+		filePath := path.Clean(file.Path)
+		paths[filePath] = true
+		m := g.module(path.Dir(filePath))
+		m.Files = append(m.Files, filePath)
+
+		if path.Base(filePath) == "go.mod" {
+			if match := goModRegex.FindStringSubmatch(file.Contents); match != nil {
+				goModules[path.Dir(filePath)] = match[1]
+			}
+		}
+	}
+
+	imports := map[string]map[string]bool{}
+	external := map[string]map[string]bool{}
+	add := func(set map[string]map[string]bool, dir, value string) {
+		if set[dir] == nil {
+			set[dir] = map[string]bool{}
+		}
+		set[dir][value] = true
+	}
+
+	for _, file := range files {
+		filePath := path.Clean(file.Path)
+		dir := path.Dir(filePath)
+
+		var internal, outside []string
+		switch ext := path.Ext(filePath); {
+		case ext == ".go":
+			internal, outside = g.goImports(filePath, file.Contents, goModules)
+		case utils.Contains(jsExtensions, ext):
+			internal, outside = g.jsImports(dir, file.Contents, paths)
+		case ext == ".py":
+			internal, outside = g.pyImports(dir, file.Contents, paths)
+		}
+
+		for _, imp := range internal {
+			if imp != dir {
+				add(imports, dir, imp)
+			}
+		}
+		for _, imp := range outside {
+			add(external, dir, imp)
+		}
+	}
+
+	for dir, m := range g.Modules {
+		m.Imports = sortedKeys(imports[dir])
+		m.External = sortedKeys(external[dir])
+		sort.Strings(m.Files)
+		sort.Strings(m.Children)
+	}
+
+	return g
+}
+
+// returns the module for the directory, creating it and its parents if needed
+func (g *Graph) module(dir string) *Module {
+	if m, ok := g.Modules[dir]; ok {
+		return m
+	}
+	m := &Module{Path: dir}
+	g.Modules[dir] = m
+	if dir != "." {
+		parent := g.module(path.Dir(dir))
+		parent.Children = append(parent.Children, dir)
+	}
+	return m
+}
+
+func (g *Graph) goImports(filePath, contents string, goModules map[string]string) ([]string, []string) {
+	f, err := parser.ParseFile(token.NewFileSet(), filePath, contents, parser.ImportsOnly)
+	if err != nil {
+		return nil, nil
+	}
+
+	var internal, outside []string
+	for _, spec := range f.Imports {
+		imp, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		found := false
+		for dir, modPath := range goModules {
+			if imp != modPath && !strings.HasPrefix(imp, modPath+"/") {
+				continue
+			}
+			target := path.Join(dir, strings.TrimPrefix(strings.TrimPrefix(imp, modPath), "/"))
+			if _, ok := g.Modules[target]; ok {
+				internal = append(internal, target)
+				found = true
+				break
+			}
+		}
+
+		// the standard library has no dot in the first element
+		if !found && strings.Contains(strings.Split(imp, "/")[0], ".") {
+			outside = append(outside, imp)
+		}
+	}
+	return internal, outside
+}
+
+func (g *Graph) jsImports(dir, contents string, paths map[string]bool) ([]string, []string) {
+	var internal, outside []string
+	for _, re := range jsImportRegexes {
+		for _, match := range re.FindAllStringSubmatch(contents, -1) {
+			spec := match[1]
+			if !strings.HasPrefix(spec, ".") {
+				// the package name, including the scope
+				parts := strings.Split(spec, "/")
+				name := parts[0]
+				if strings.HasPrefix(name, "@") && len(parts) > 1 {
+					name += "/" + parts[1]
+				}
+				outside = append(outside, name)
+				continue
+			}
+
+			if target, ok := g.resolve(path.Join(dir, spec), jsExtensions, paths); ok {
+				internal = append(internal, target)
+			}
+		}
+	}
+	return internal, outside
+}
+
+func (g *Graph) pyImports(dir, contents string, paths map[string]bool) ([]string, []string) {
+	var internal, outside []string
+	exts := []string{".py"}
+
+	for _, match := range pyFromRegex.FindAllStringSubmatch(contents, -1) {
+		dots, name := match[1], match[2]
+		if dots != "" {
+			// relative imports go up a directory for each dot after the first
+			base := dir
+			for i := 1; i < len(dots); i++ {
+				base = path.Dir(base)
+			}
+			target := path.Join(base, strings.ReplaceAll(name, ".", "/"))
+			if resolved, ok := g.resolve(target, exts, paths); ok {
+				internal = append(internal, resolved)
+			}
+			continue
+		}
+		if resolved, ok := g.resolvePython(name, paths); ok {
+			internal = append(internal, resolved)
+		} else {
+			outside = append(outside, strings.Split(name, ".")[0])
+		}
+	}
+
+	for _, match := range pyImportRegex.FindAllStringSubmatch(contents, -1) {
+		for _, name := range strings.Split(match[1], ",") {
+			name = strings.TrimSpace(name)
+			if resolved, ok := g.resolvePython(name, paths); ok {
+				internal = append(internal, resolved)
+			} else {
+				outside = append(outside, strings.Split(name, ".")[0])
+			}
+		}
+	}
+	return internal, outside
+}
+
+// resolves an absolute python import from the root of the repository or a src directory
+func (g *Graph) resolvePython(name string, paths map[string]bool) (string, bool) {
+	target := strings.ReplaceAll(name, ".", "/")
+	for _, root := range []string{"", "src"} {
+		if resolved, ok := g.resolve(path.Join(root, target), []string{".py"}, paths); ok {
+			return resolved, true
+		}
+	}
+	return "", false
+}
+
+// resolve finds the module of an import that names either a file, with or without
+// its extension, or a directory
+func (g *Graph) resolve(target string, exts []string, paths map[string]bool) (string, bool) {
+	if paths[target] {
+		return path.Dir(target), true
+	}
+	for _, ext := range exts {
+		if paths[target+ext] {
+			return path.Dir(target), true
+		}
+	}
+	if m, ok := g.Modules[target]; ok && len(m.Files) > 0 {
+		return target, true
+	}
+	return "", false
+}
+
+// Paths returns the paths of the modules, sorted
+func (g *Graph) Paths() []string {
+	var paths []string
+	for p := range g.Modules {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Dependents returns the modules that import the module
+func (g *Graph) Dependents(modulePath string) []string {
+	var dependents []string
+	for _, p := range g.Paths() {
+		if utils.Contains(g.Modules[p].Imports, modulePath) {
+			dependents = append(dependents, p)
+		}
+	}
+	return dependents
+}
+
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
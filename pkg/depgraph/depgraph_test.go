@@ -0,0 +1,69 @@
+package depgraph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+func TestBuild(t *testing.T) {
+	files := []prompt.GitFile{
+		{Path: "go.mod", Contents: "module example.com/app\n\ngo 1.20\n"},
+		{Path: "main.go", Contents: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/pkg/api\"\n\t\"github.com/spf13/cobra\"\n)\n"},
+		{Path: "pkg/api/api.go", Contents: "package api\n\nimport \"example.com/app/pkg/db\"\n"},
+		{Path: "pkg/db/db.go", Contents: "package db\n"},
+		{Path: "web/src/app.ts", Contents: "import { get } from './lib/http'\nimport React from 'react'\nconst x = require('@scope/pkg/sub')\n"},
+		{Path: "web/src/lib/http.ts", Contents: "export const get = () => {}\n"},
+		{Path: "server/app.py", Contents: "from .models import User\nimport os, server.utils\n"},
+		{Path: "server/models/__init__.py", Contents: ""},
+		{Path: "server/utils.py", Contents: ""},
+	}
+
+	g := Build(files)
+
+	testCases := []struct {
+		module   string
+		imports  []string
+		external []string
+	}{
+		{".", []string{"pkg/api"}, []string{"github.com/spf13/cobra"}},
+		{"pkg/api", []string{"pkg/db"}, nil},
+		{"web/src", []string{"web/src/lib"}, []string{"@scope/pkg", "react"}},
+		{"server", []string{"server/models"}, []string{"os"}},
+	}
+
+	for _, tc := range testCases {
+		m, ok := g.Modules[tc.module]
+		if !ok {
+			t.Errorf("Expected module %s", tc.module)
+			continue
+		}
+		if !reflect.DeepEqual(m.Imports, tc.imports) {
+			t.Errorf("Expected %s to import %v, but got %v", tc.module, tc.imports, m.Imports)
+		}
+		if !reflect.DeepEqual(m.External, tc.external) {
+			t.Errorf("Expected %s to have external imports %v, but got %v", tc.module, tc.external, m.External)
+		}
+	}
+
+	if !reflect.DeepEqual(g.Modules["pkg"].Children, []string{"pkg/api", "pkg/db"}) {
+		t.Errorf("Expected pkg to have the children pkg/api and pkg/db, but got %v", g.Modules["pkg"].Children)
+	}
+
+	if !reflect.DeepEqual(g.Dependents("pkg/db"), []string{"pkg/api"}) {
+		t.Errorf("Expected pkg/db to be used by pkg/api, but got %v", g.Dependents("pkg/db"))
+	}
+
+	diagram := g.Mermaid(40)
+	if !strings.HasPrefix(diagram, "graph LR\n") || !strings.Contains(diagram, "[\"pkg/db\"]") {
+		t.Errorf("Unexpected diagram:\n%s", diagram)
+	}
+
+	// grouping by the top level directories
+	collapsed := g.Mermaid(5)
+	if strings.Contains(collapsed, "pkg/db") || !strings.Contains(collapsed, "[\"pkg\"]") {
+		t.Errorf("Expected the modules to be grouped, but got:\n%s", collapsed)
+	}
+}
@@ -0,0 +1,210 @@
+package docstyle
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// max width of a generated comment line, not counting indentation and comment markers
+const Width = 77
+
+// Style is a way of writing doc comments for a language
+type Style struct {
+	Name string
+	// Guide tells the model how to write the comment text for this style
+	Guide string
+	// Docstring styles are written inside the body of the declaration instead of above it
+	Docstring bool
+	format    func(indent, commentOperator string, lines []string) []string
+}
+
+// Format wraps the comment text and adds the comment markers for the style.
+// commentOperator is only used by the line comment style.
+func (s *Style) Format(indent, commentOperator, text string) []string {
+	return s.format(indent, commentOperator, Wrap(text, Width))
+}
+
+var Line = &Style{
+	Name:  "line",
+	Guide: "Write the comment as plain text.",
+	format: func(indent, commentOperator string, lines []string) []string {
+		return prefixLines(indent, commentOperator, lines)
+	},
+}
+
+var styles = map[string]*Style{
+	"line": Line,
+	"google": {
+		Name:      "google",
+		Guide:     "Write the comment as the contents of a Google style Python docstring: a one line summary, a blank line, then \"Args:\", \"Returns:\" and \"Raises:\" sections where they apply, with each entry indented by 4 spaces.",
+		Docstring: true,
+		format:    pythonDocstring,
+	},
+	"numpy": {
+		Name:      "numpy",
+		Guide:     "Write the comment as the contents of a NumPy style Python docstring: a one line summary, a blank line, then \"Parameters\", \"Returns\" and \"Raises\" sections where they apply, each underlined with dashes, with entries in the form \"name : type\" followed by an indented description.",
+		Docstring: true,
+		format:    pythonDocstring,
+	},
+	"sphinx": {
+		Name:      "sphinx",
+		Guide:     "Write the comment as the contents of a Sphinx (reStructuredText) style Python docstring: a one line summary, a blank line, then \":param name: description\", \":type name: type\", \":returns: description\", \":rtype: type\" and \":raises Error: description\" fields where they apply.",
+		Docstring: true,
+		format:    pythonDocstring,
+	},
+	"jsdoc": {
+		Name:   "jsdoc",
+		Guide:  "Write the comment as the contents of a JSDoc block: a summary, a blank line, then \"@param {type} name - description\", \"@returns {type} description\" and \"@throws {type} description\" tags where they apply.",
+		format: blockComment,
+	},
+	"tsdoc": {
+		Name:   "tsdoc",
+		Guide:  "Write the comment as the contents of a TSDoc block: a summary, a blank line, then \"@param name - description\", \"@returns description\" and \"@throws description\" tags where they apply. Do not include types in the tags.",
+		format: blockComment,
+	},
+	"javadoc": {
+		Name:   "javadoc",
+		Guide:  "Write the comment as the contents of a Javadoc block: a summary sentence, a blank line, then \"@param name description\", \"@return description\" and \"@throws Exception description\" tags where they apply.",
+		format: blockComment,
+	},
+	"rustdoc": {
+		Name:  "rustdoc",
+		Guide: "Write the comment as Rust documentation in markdown: a one line summary, a blank line, then \"# Arguments\", \"# Returns\", \"# Errors\" and \"# Panics\" sections where they apply, with arguments as a bulleted list.",
+		format: func(indent, commentOperator string, lines []string) []string {
+			return prefixLines(indent, "///", lines)
+		},
+	},
+}
+
+// the styles that can be used for each language. The first one is the default.
+var languageStyles = map[string][]string{
+	"python":     {"google", "numpy", "sphinx", "line"},
+	"javascript": {"jsdoc", "line"},
+	"typescript": {"tsdoc", "jsdoc", "line"},
+	"java":       {"javadoc", "line"},
+	"rust":       {"rustdoc"},
+}
+
+var languages = map[string]string{
+	".py":   "python",
+	".js":   "javascript",
+	".jsx":  "javascript",
+	".mjs":  "javascript",
+	".ts":   "typescript",
+	".tsx":  "typescript",
+	".java": "java",
+	".rs":   "rust",
+}
+
+// Language returns the name of the language of the file, or an empty string
+// if the language only supports line comments.
+func Language(path string) string {
+	return languages[strings.ToLower(filepath.Ext(path))]
+}
+
+// Languages returns the names of the languages that support docstring styles
+func Languages() []string {
+	var names []string
+	for name := range languageStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate makes sure the style can be used for the language
+func Validate(language, style string) error {
+	allowed, ok := languageStyles[language]
+	if !ok {
+		return fmt.Errorf("unsupported language %s. Valid languages are: %s", language, strings.Join(Languages(), ", "))
+	}
+	for _, name := range allowed {
+		if name == style {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported style %s for %s. Valid styles are: %s", style, language, strings.Join(allowed, ", "))
+}
+
+// ForFile returns the style to use for the file. configured maps language names
+// to style names, languages missing from it use their default style.
+func ForFile(path string, configured map[string]string) (*Style, error) {
+	language := Language(path)
+	if language == "" {
+		return Line, nil
+	}
+
+	name, ok := configured[language]
+	if !ok || name == "" {
+		name = languageStyles[language][0]
+	}
+
+	err := Validate(language, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return styles[name], nil
+}
+
+// Wrap wraps the text into lines of at most width characters. Existing line breaks
+// and the indentation of each line are kept.
+func Wrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		paragraph = strings.TrimRight(paragraph, " \t")
+		indent := paragraph[:len(paragraph)-len(strings.TrimLeft(paragraph, " \t"))]
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := indent + words[0]
+		for _, word := range words[1:] {
+			if len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = indent + word
+				continue
+			}
+			line += " " + word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func prefixLines(indent, prefix string, lines []string) []string {
+	var formatted []string
+	for _, line := range lines {
+		if line == "" {
+			formatted = append(formatted, indent+prefix)
+			continue
+		}
+		formatted = append(formatted, indent+prefix+" "+line)
+	}
+	return formatted
+}
+
+func blockComment(indent, commentOperator string, lines []string) []string {
+	formatted := []string{indent + "/**"}
+	formatted = append(formatted, prefixLines(indent, " *", lines)...)
+	return append(formatted, indent+" */")
+}
+
+func pythonDocstring(indent, commentOperator string, lines []string) []string {
+	if len(lines) == 1 {
+		return []string{indent + `"""` + lines[0] + `"""`}
+	}
+
+	formatted := []string{indent + `"""` + lines[0]}
+	for _, line := range lines[1:] {
+		if line == "" {
+			formatted = append(formatted, "")
+			continue
+		}
+		formatted = append(formatted, indent+line)
+	}
+	return append(formatted, indent+`"""`)
+}
@@ -0,0 +1,265 @@
+package drift
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/state"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/symbols"
+)
+
+// package for pairing documentation with the code it describes, so the pairs can be
+// checked for drift. Pairs that were verified are remembered by the hash of the
+// documentation and the signature of the symbol, so they're only checked again when one changes.
+
+// the most lines of code or markdown sent for a pair
+const maxLines = 60
+
+// Symbol is a declaration along with its code
+type Symbol struct {
+	// slash separated path of the file relative to the repository root
+	Path   string
+	Symbol symbols.Symbol
+	// the declaration without its doc comment
+	Code string
+	// the parts of the declaration callers depend on, see Signature
+	Signature string
+}
+
+// Pair is documentation and the symbol it describes
+type Pair struct {
+	// where the documentation is, the path relative to the repository root and a 1-indexed line
+	DocPath string
+	DocLine int
+	Doc     string
+	Symbol  Symbol
+}
+
+// ID identifies the pair independently of line numbers
+func (p Pair) ID() string {
+	return p.DocPath + ":" + p.Symbol.Path + ":" + p.Symbol.Symbol.Key()
+}
+
+// Hash changes whenever the documentation or the signature of the symbol does
+func (p Pair) Hash() string {
+	sum := sha256.Sum256([]byte(p.Doc + "\x00" + p.Symbol.Signature))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Location is where the documentation is, in the form path:line
+func (p Pair) Location() string {
+	return fmt.Sprintf("%s:%d", p.DocPath, p.DocLine)
+}
+
+// Verified maps pair IDs to the hash of the pair when it was last verified
+type Verified map[string]string
+
+// kinds whose whole declaration is the signature, rather than just the header
+var declarationKinds = map[string]bool{"type": true, "struct": true, "interface": true, "enum": true, "const": true, "var": true, "let": true, "trait": true}
+
+// Signature returns the parts of the declaration that callers depend on. For functions and
+// classes it's the header up to the opening of the body, for types and values the whole declaration.
+func Signature(lines []string, sym symbols.Symbol) string {
+	end := sym.EndLine
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	var out []string
+	for i := sym.Line; i <= end; i++ {
+		line := lines[i-1]
+		out = append(out, strings.TrimSpace(line))
+		if declarationKinds[sym.Kind] {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, ":") || strings.HasSuffix(trimmed, "}") {
+			break
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// Symbols returns the symbols declared in the file with their code
+func Symbols(path, contents string) ([]Symbol, error) {
+	syms, err := symbols.Parse(path, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(contents, "\n")
+	var out []Symbol
+	for _, sym := range syms {
+		out = append(out, Symbol{
+			Path:      path,
+			Symbol:    sym,
+			Code:      code(lines, sym),
+			Signature: Signature(lines, sym),
+		})
+	}
+	return out, nil
+}
+
+// the code of the declaration without its doc comment or docstring
+func code(lines []string, sym symbols.Symbol) string {
+	var out []string
+	for i := sym.StartLine; i <= sym.EndLine && i <= len(lines); i++ {
+		if sym.DocstringStart > 0 && i >= sym.DocstringStart && i <= sym.DocstringEnd {
+			continue
+		}
+		out = append(out, lines[i-1])
+		if len(out) == maxLines {
+			out = append(out, "...")
+			break
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// CommentPairs pairs the doc comments and docstrings in the file with the symbols they document
+func CommentPairs(syms []Symbol, contents string) []Pair {
+	lines := strings.Split(contents, "\n")
+	var pairs []Pair
+	for _, sym := range syms {
+		start, end := sym.Symbol.DocStart, sym.Symbol.DocEnd
+		if start == 0 {
+			start, end = sym.Symbol.DocstringStart, sym.Symbol.DocstringEnd
+		}
+		if start == 0 {
+			continue
+		}
+		pairs = append(pairs, Pair{
+			DocPath: sym.Path,
+			DocLine: start,
+			Doc:     strings.Join(lines[start-1:end], "\n"),
+			Symbol:  sym,
+		})
+	}
+	return pairs
+}
+
+// Index finds symbols by their key or name. Only exported symbols with a unique name can be
+// found, so a mention in the docs can't be paired with the wrong declaration.
+type Index map[string][]Symbol
+
+// NewIndex indexes the symbols
+func NewIndex(syms []Symbol) Index {
+	index := Index{}
+	for _, sym := range syms {
+		if !sym.Symbol.Exported {
+			continue
+		}
+		index[sym.Symbol.Key()] = append(index[sym.Symbol.Key()], sym)
+		if sym.Symbol.Receiver != "" {
+			index[sym.Symbol.Name] = append(index[sym.Symbol.Name], sym)
+		}
+	}
+	return index
+}
+
+// Find returns the symbol with the key or name, if there is exactly one
+func (idx Index) Find(name string) (Symbol, bool) {
+	syms := idx[name]
+	if len(syms) != 1 {
+		return Symbol{}, false
+	}
+	return syms[0], true
+}
+
+var headingRegex = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+var mentionRegex = regexp.MustCompile("`([A-Za-z_][\\w.]*)(?:\\([^)`]*\\))?`")
+
+// MarkdownPairs pairs the sections of the markdown file with the symbols they mention, either in
+// inline code or as the heading of the section
+func MarkdownPairs(path, contents string, index Index) []Pair {
+	lines := strings.Split(contents, "\n")
+
+	type section struct {
+		start, end int
+	}
+	var sections []section
+	current := section{start: 1}
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		if !inCode && headingRegex.MatchString(line) && i > 0 {
+			current.end = i
+			sections = append(sections, current)
+			current = section{start: i + 1}
+		}
+	}
+	current.end = len(lines)
+	sections = append(sections, current)
+
+	// each symbol is paired with the first section that mentions it
+	seen := map[string]bool{}
+	var pairs []Pair
+	for _, s := range sections {
+		text := lines[s.start-1 : s.end]
+		if len(text) > maxLines {
+			text = append(text[:maxLines:maxLines], "...")
+		}
+
+		inCode := false
+		for i := s.start; i <= s.end; i++ {
+			line := lines[i-1]
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				inCode = !inCode
+			}
+			if inCode {
+				continue
+			}
+
+			var names []string
+			if match := headingRegex.FindStringSubmatch(line); match != nil {
+				heading := strings.Trim(strings.TrimSpace(match[2]), "`")
+				names = append(names, strings.TrimSuffix(heading, "()"))
+			}
+			for _, match := range mentionRegex.FindAllStringSubmatch(line, -1) {
+				names = append(names, match[1])
+			}
+
+			for _, name := range names {
+				sym, ok := index.Find(name)
+				if !ok || seen[sym.Path+":"+sym.Symbol.Key()] {
+					continue
+				}
+				seen[sym.Path+":"+sym.Symbol.Key()] = true
+				pairs = append(pairs, Pair{
+					DocPath: path,
+					DocLine: i,
+					Doc:     strings.Join(text, "\n"),
+					Symbol:  sym,
+				})
+			}
+		}
+	}
+	return pairs
+}
+
+const verifiedFileName = "verified.json"
+
+// LoadVerified loads the pairs verified in the repository at root
+func LoadVerified(root string) (Verified, error) {
+	verified := Verified{}
+	err := state.Load(root, verifiedFileName, &verified)
+	if err != nil {
+		return nil, err
+	}
+	return verified, nil
+}
+
+// Save saves the verified pairs for the repository at root
+func (v Verified) Save(root string) error {
+	return state.Save(root, verifiedFileName, v)
+}
+
+// Unchanged reports whether the pair is the same as when it was last verified
+func (v Verified) Unchanged(pair Pair) bool {
+	return v[pair.ID()] == pair.Hash()
+}
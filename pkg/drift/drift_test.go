@@ -0,0 +1,68 @@
+package drift
+
+import (
+	"testing"
+)
+
+func TestPairs(t *testing.T) {
+	code := `package store
+
+// Open opens the store at path.
+func Open(path string) (*Store, error) {
+	return nil, nil
+}
+
+// Store holds things.
+type Store struct {
+	Path string
+}
+
+func (s *Store) Close() error {
+	return nil
+}
+`
+
+	syms, err := Symbols("store/store.go", code)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	comments := CommentPairs(syms, code)
+	if len(comments) != 2 {
+		t.Fatalf("Expected 2 comment pairs, but got %d", len(comments))
+	}
+	if comments[0].Symbol.Symbol.Key() != "Open" || comments[0].Location() != "store/store.go:3" || comments[0].Doc != "// Open opens the store at path." {
+		t.Errorf("Unexpected pair: %+v", comments[0])
+	}
+	if comments[0].Symbol.Signature != "func Open(path string) (*Store, error) {" {
+		t.Errorf("Unexpected signature: %q", comments[0].Symbol.Signature)
+	}
+	if comments[1].Symbol.Signature != "type Store struct {\nPath string\n}" {
+		t.Errorf("Unexpected signature: %q", comments[1].Symbol.Signature)
+	}
+
+	markdown := "# Guide\n\nCall `Open()` first.\n\n## Close\n\nCloses it.\n\n```go\nOpen(\"x\")\n```\n"
+	pairs := MarkdownPairs("README.md", markdown, NewIndex(syms))
+	if len(pairs) != 2 {
+		t.Fatalf("Expected 2 markdown pairs, but got %d: %+v", len(pairs), pairs)
+	}
+	if pairs[0].Symbol.Symbol.Key() != "Open" || pairs[0].DocLine != 3 {
+		t.Errorf("Unexpected pair: %+v", pairs[0])
+	}
+	if pairs[1].Symbol.Symbol.Key() != "Store.Close" || pairs[1].DocLine != 5 || pairs[1].Doc != "## Close\n\nCloses it.\n\n```go\nOpen(\"x\")\n```\n" {
+		t.Errorf("Unexpected pair: %+v", pairs[1])
+	}
+
+	// the hash only changes with the documentation or the signature
+	verified := Verified{}
+	verified[comments[0].ID()] = comments[0].Hash()
+	changed := comments[0]
+	changed.Symbol.Code = "different body"
+	if !verified.Unchanged(changed) {
+		t.Errorf("Expected a change to the body to keep the pair verified")
+	}
+	changed.Symbol.Signature = "func Open(path string, mode int) (*Store, error) {"
+	if verified.Unchanged(changed) {
+		t.Errorf("Expected a change to the signature to need checking again")
+	}
+}
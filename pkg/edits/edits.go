@@ -0,0 +1,168 @@
+package edits
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/textfile"
+)
+
+// package for applying the edits the model makes to a set of files. Edits are search and replace
+// blocks, the model only writes the code that changes along with enough around it to find it,
+// rather than the whole file.
+
+// Edit replaces code in a file
+type Edit struct {
+	// slash separated path of the file
+	Path string `json:"path"`
+	// the code to replace, copied from the file. Empty to create the file or add to its end.
+	Search string `json:"search"`
+	// the code to replace it with
+	Replace string `json:"replace"`
+}
+
+// Failure is an edit that couldn't be applied and why
+type Failure struct {
+	Edit   Edit
+	Reason string
+	// the code in the file nearest to the search text, if any is close
+	Nearest string
+}
+
+// ApplyError is returned when some of the edits couldn't be applied
+type ApplyError struct {
+	Failures []Failure
+}
+
+// Feedback explains the failures to the model so it can correct the edits
+func (e *ApplyError) Feedback() string {
+	var b strings.Builder
+	for _, failure := range e.Failures {
+		fmt.Fprintf(&b, "The edit to %s failed: %s.\nSEARCH:\n%s\n", failure.Edit.Path, failure.Reason, failure.Edit.Search)
+		if failure.Nearest != "" {
+			fmt.Fprintf(&b, "The nearest code in the file is:\n%s\n", failure.Nearest)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (e *ApplyError) Error() string {
+	var lines []string
+	for _, failure := range e.Failures {
+		lines = append(lines, fmt.Sprintf("%s: %s", failure.Edit.Path, failure.Reason))
+	}
+	return "some edits could not be applied:\n" + strings.Join(lines, "\n")
+}
+
+// ChangeSet is the files before and after a set of edits
+type ChangeSet struct {
+	// the contents of the files before the edits by path, files that don't exist are missing
+	Original map[string]string
+	// the contents of the changed files by path
+	Updated map[string]string
+}
+
+// Paths returns the paths of the changed files, sorted
+func (c *ChangeSet) Paths() []string {
+	var paths []string
+	for p := range c.Updated {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Created reports whether the file doesn't exist yet
+func (c *ChangeSet) Created(p string) bool {
+	_, ok := c.Original[p]
+	return !ok
+}
+
+// Diff returns the unified diff of the changes
+func (c *ChangeSet) Diff() string {
+	var b strings.Builder
+	for _, p := range c.Paths() {
+		oldName := "a/" + p
+		if c.Created(p) {
+			oldName = "/dev/null"
+		}
+		b.WriteString(textfile.Diff(oldName, "b/"+p, c.Original[p], c.Updated[p]))
+	}
+	return b.String()
+}
+
+// NewChangeSet starts a change set for the files, which are the contents by path
+func NewChangeSet(files map[string]string) *ChangeSet {
+	changes := &ChangeSet{Original: map[string]string{}, Updated: map[string]string{}}
+	for p, contents := range files {
+		changes.Original[p] = contents
+	}
+	return changes
+}
+
+// Apply applies the edits to the files, which are the contents by path. Only the given files
+// can be changed, other paths are created and must not exist or leave the current directory. Every edit is tried, and if any fail
+// an *ApplyError lists them along with the change set of the edits that succeeded.
+func Apply(files map[string]string, edits []Edit, exists func(path string) bool) (*ChangeSet, error) {
+	changes := NewChangeSet(files)
+	return changes, changes.Apply(edits, exists)
+}
+
+// Apply applies more edits on top of the changes so far. Returns an *ApplyError if any fail,
+// the others are still applied.
+func (c *ChangeSet) Apply(edits []Edit, exists func(path string) bool) error {
+	var failures []Failure
+	for _, edit := range edits {
+		// the model sometimes writes Windows paths
+		edit.Path = path.Clean(strings.TrimPrefix(strings.ReplaceAll(edit.Path, "\\", "/"), "./"))
+
+		current, ok := c.Updated[edit.Path]
+		if !ok {
+			current, ok = c.Original[edit.Path]
+		}
+		if !ok && !insideDir(edit.Path) {
+			failures = append(failures, Failure{Edit: edit, Reason: "the path must be relative to the current directory and stay inside it"})
+			continue
+		}
+		if !ok && exists != nil && exists(edit.Path) {
+			failures = append(failures, Failure{Edit: edit, Reason: "the file was not given to edit, only the given files can be changed"})
+			continue
+		}
+
+		updated, err := applyEdit(current, edit)
+		if err != nil {
+			failure := Failure{Edit: edit, Reason: err.Error()}
+			if match, ok := err.(*matchError); ok {
+				failure.Nearest = match.nearest
+			}
+			failures = append(failures, failure)
+			continue
+		}
+		c.Updated[edit.Path] = updated
+	}
+
+	// edits that undo each other leave nothing to change
+	for p, contents := range c.Updated {
+		if original, ok := c.Original[p]; ok && original == contents {
+			delete(c.Updated, p)
+		}
+	}
+
+	if len(failures) > 0 {
+		return &ApplyError{Failures: failures}
+	}
+	return nil
+}
+
+// insideDir reports whether the cleaned, slash separated path is relative and doesn't leave
+// the current directory
+func insideDir(p string) bool {
+	if p == "." || p == "" || path.IsAbs(p) || filepath.IsAbs(filepath.FromSlash(p)) || filepath.VolumeName(p) != "" {
+		return false
+	}
+	return p != ".." && !strings.HasPrefix(p, "../")
+}
@@ -0,0 +1,92 @@
+package edits
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	files := map[string]string{
+		"a.go": "package a\n\nfunc Load() {}\n",
+		"b.go": "package b\n\nfunc use() {\n\ta.Load()\n\ta.Load()\n}\n",
+	}
+	exists := func(path string) bool { return path == "c.go" }
+
+	changes, err := Apply(files, []Edit{
+		{Path: "a.go", Search: "func Load()", Replace: "func Read()"},
+		{Path: "./b.go", Search: "\ta.Load()\n\ta.Load()", Replace: "\ta.Read()\n\ta.Read()"},
+		{Path: "new.go", Search: "", Replace: "package a\n"},
+	}, exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes.Updated["a.go"] != "package a\n\nfunc Read() {}\n" {
+		t.Errorf("unexpected a.go: %q", changes.Updated["a.go"])
+	}
+	if !strings.Contains(changes.Updated["b.go"], "a.Read()\n\ta.Read()") {
+		t.Errorf("unexpected b.go: %q", changes.Updated["b.go"])
+	}
+	if !changes.Created("new.go") || !strings.Contains(changes.Diff(), "--- /dev/null\n+++ b/new.go") {
+		t.Errorf("expected new.go to be created, got diff:\n%s", changes.Diff())
+	}
+
+	_, err = Apply(files, []Edit{
+		{Path: "b.go", Search: "a.Load()", Replace: "a.Read()"},
+		{Path: "a.go", Search: "func Missing()", Replace: ""},
+		{Path: "c.go", Search: "", Replace: "package c\n"},
+		{Path: "/etc/cron.d/job", Search: "", Replace: "* * * * * true\n"},
+		{Path: "pkg/../../outside.go", Search: "", Replace: "package outside\n"},
+	}, exists)
+	var applyErr *ApplyError
+	if !errors.As(err, &applyErr) || len(applyErr.Failures) != 5 {
+		t.Fatalf("expected 5 failures, got %v", err)
+	}
+	for _, failure := range applyErr.Failures[3:] {
+		if !strings.Contains(failure.Reason, "stay inside it") {
+			t.Errorf("expected %s to be rejected as outside the directory, got %s", failure.Edit.Path, failure.Reason)
+		}
+	}
+	if !strings.Contains(applyErr.Failures[0].Reason, "2 times") {
+		t.Errorf("expected an ambiguous match, got %s", applyErr.Failures[0].Reason)
+	}
+}
+
+func TestApplyFuzzy(t *testing.T) {
+	file := "func main() {\n\tif ok {\n\t\tfmt.Println(\"hello world\")\n\t}\n}\n"
+
+	// the model dropped the indentation, the replacement is indented to match the file
+	updated, err := applyEdit(file, Edit{Search: "if ok {\n  fmt.Println(\"hello world\")\n}", Replace: "if ok {\n  fmt.Println(\"hi\")\n}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != "func main() {\n\tif ok {\n\t  fmt.Println(\"hi\")\n\t}\n}\n" {
+		t.Errorf("unexpected whitespace insensitive edit: %q", updated)
+	}
+
+	// a small typo still matches the nearest line
+	updated, err = applyEdit(file, Edit{Search: "\t\tfmt.Println(\"hello wrld\")", Replace: "\t\tfmt.Println(\"bye\")"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(updated, "\t\tfmt.Println(\"bye\")\n\t}") {
+		t.Errorf("unexpected nearest edit: %q", updated)
+	}
+
+	// too different to guess, but the nearest code is suggested
+	changes := NewChangeSet(map[string]string{"main.go": file})
+	err = changes.Apply([]Edit{{Path: "main.go", Search: "if ok {\n\tfmt.Printf(\"%s\", greeting)\n}", Replace: ""}}, nil)
+	var applyErr *ApplyError
+	if !errors.As(err, &applyErr) {
+		t.Fatalf("expected the edit to fail, got %v", err)
+	}
+	if applyErr.Failures[0].Nearest == "" || !strings.Contains(applyErr.Feedback(), "The nearest code in the file is:\n\tif ok {") {
+		t.Errorf("expected the nearest code in the feedback, got:\n%s", applyErr.Feedback())
+	}
+
+	// a search longer than the file can't match
+	_, err = Apply(map[string]string{"a.go": "package a\n"}, []Edit{{Path: "a.go", Search: "package a\n\nfunc X() {}\n\nfunc Y() {}"}}, nil)
+	if !errors.As(err, &applyErr) {
+		t.Fatalf("expected the edit to fail, got %v", err)
+	}
+}
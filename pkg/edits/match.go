@@ -0,0 +1,204 @@
+package edits
+
+import (
+	"fmt"
+	"strings"
+)
+
+// how similar the nearest code has to be to the search text to be replaced without an exact match
+const nearestThreshold = 0.9
+
+// how similar the nearest code has to be to be suggested when an edit fails
+const suggestThreshold = 0.5
+
+// matchError is an edit whose search text couldn't be matched, with the code nearest to it
+type matchError struct {
+	reason  string
+	nearest string
+}
+
+func (e *matchError) Error() string {
+	return e.reason
+}
+
+// applyEdit replaces the search text in the contents. The search text has to appear once, so
+// the edit can't change the wrong code. If it doesn't appear exactly, the lines that match it
+// ignoring whitespace are replaced, and failing that the nearest lines if they are close enough.
+func applyEdit(contents string, edit Edit) (string, error) {
+	if edit.Search == "" {
+		if contents != "" && !strings.HasSuffix(contents, "\n") {
+			contents += "\n"
+		}
+		return contents + edit.Replace, nil
+	}
+
+	switch count := strings.Count(contents, edit.Search); count {
+	case 0:
+	case 1:
+		return strings.Replace(contents, edit.Search, edit.Replace, 1), nil
+	default:
+		return "", fmt.Errorf("the search text was found %d times, include more of the surrounding code so it's unique", count)
+	}
+
+	lines := strings.Split(contents, "\n")
+	search := splitLines(edit.Search)
+	if len(search) == 0 {
+		return "", fmt.Errorf("the search text is only whitespace")
+	}
+
+	// the same lines, ignoring whitespace
+	var found []int
+	for i := 0; i+len(search) <= len(lines); i++ {
+		if similarity(lines[i:i+len(search)], search, true) == 1 {
+			found = append(found, i)
+		}
+	}
+	if len(found) > 1 {
+		return "", fmt.Errorf("the search text was found %d times ignoring whitespace, include more of the surrounding code so it's unique", len(found))
+	}
+	if len(found) == 1 {
+		return replaceLines(lines, found[0], search, edit.Replace), nil
+	}
+
+	if len(search) > len(lines) {
+		return "", &matchError{reason: "the search text was not found, it is longer than the file"}
+	}
+
+	// the nearest lines, which must be closer than any others that don't overlap them
+	scores := make([]float64, len(lines)-len(search)+1)
+	best := 0
+	for i := range scores {
+		scores[i] = similarity(lines[i:i+len(search)], search, false)
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	unique := true
+	for i, score := range scores {
+		if score >= scores[best] && (i <= best-len(search) || i >= best+len(search)) {
+			unique = false
+		}
+	}
+	if scores[best] >= nearestThreshold && unique {
+		return replaceLines(lines, best, search, edit.Replace), nil
+	}
+
+	err := &matchError{reason: "the search text was not found"}
+	if scores[best] >= suggestThreshold {
+		err.nearest = strings.Join(lines[best:best+len(search)], "\n")
+	}
+	return "", err
+}
+
+// splitLines splits the text into lines, without the blank lines at its start and end
+func splitLines(text string) []string {
+	lines := strings.Split(text, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// replaceLines replaces the lines starting at start that matched the search lines. The
+// replacement is indented by the difference between the file and the search text, as the
+// model often gets the indentation wrong.
+func replaceLines(lines []string, start int, search []string, replace string) string {
+	fileIndent, searchIndent := indentation(lines[start:start+len(search)]), indentation(search)
+
+	var replacement []string
+	if strings.TrimSpace(replace) != "" {
+		replacement = strings.Split(strings.TrimSuffix(replace, "\n"), "\n")
+		for len(replacement) > 0 && strings.TrimSpace(replacement[0]) == "" {
+			replacement = replacement[1:]
+		}
+	}
+	if fileIndent != searchIndent {
+		for i, line := range replacement {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			replacement[i] = fileIndent + strings.TrimPrefix(line, searchIndent)
+		}
+	}
+
+	out := append(append(append([]string{}, lines[:start]...), replacement...), lines[start+len(search):]...)
+	return strings.Join(out, "\n")
+}
+
+// indentation returns the indentation of the first line that isn't blank
+func indentation(lines []string) string {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		}
+	}
+	return ""
+}
+
+// similarity scores how alike the lines are from 0 to 1, ignoring differences in whitespace.
+// If exact is true it returns 1 if every line is the same and 0 otherwise.
+func similarity(a, b []string, exact bool) float64 {
+	total := 0.0
+	for i := range a {
+		x, y := normalize(a[i]), normalize(b[i])
+		if x == y {
+			total++
+			continue
+		}
+		if exact {
+			return 0
+		}
+		total += ratio(x, y)
+	}
+	return total / float64(len(a))
+}
+
+// normalize collapses the whitespace in the line
+func normalize(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// ratio is how alike two strings are from 0 to 1, based on their edit distance
+func ratio(a, b string) float64 {
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -1,50 +1,238 @@
 package gh
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/TimeSurgeLabs/ottodocs/pkg/config"
 )
 
-func CreateDraftRelease(owner, repo, title, body, tag string, conf *config.Config) error {
+// ErrReleaseNotFound is returned by GetReleaseByTag when no release exists for the tag
+var ErrReleaseNotFound = errors.New("release not found")
+
+// the most releases the GitHub API returns per page
+const releasesPerPage = 100
+
+// matches semver tags with a pre-release suffix, e.g. v1.2.0-rc.1 or 2.0.0-beta
+var prereleaseRegex = regexp.MustCompile(`^v?\d+(\.\d+){0,2}-[0-9A-Za-z.-]+(\+[0-9A-Za-z.-]+)?$`)
+
+type Release struct {
+	// The name of the tag the release is created from.
+	TagName string `json:"tag_name"`
+	// The name of the release.
+	Name string `json:"name"`
+	// Text describing the contents of the release.
+	Body string `json:"body"`
+	// Set to true to create an unpublished release.
+	Draft bool `json:"draft"`
+	// Set to true to identify the release as a prerelease.
+	Prerelease bool `json:"prerelease"`
+}
+
+type ReleaseAsset struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ReleaseResponse struct {
+	ID         int            `json:"id"`
+	TagName    string         `json:"tag_name"`
+	Name       string         `json:"name"`
+	Draft      bool           `json:"draft"`
+	Prerelease bool           `json:"prerelease"`
+	HTMLURL    string         `json:"html_url"`
+	UploadURL  string         `json:"upload_url"`
+	Assets     []ReleaseAsset `json:"assets"`
+}
+
+// IsPrerelease reports whether the tag carries a semver pre-release suffix
+func IsPrerelease(tag string) bool {
+	return prereleaseRegex.MatchString(tag)
+}
+
+func releaseRequest(method, releaseURL string, release *Release, expectedStatus int, conf *config.Config) (*ReleaseResponse, error) {
 	if conf.GHToken == "" {
-		return fmt.Errorf("no GitHub token found")
+		return nil, fmt.Errorf("no GitHub token found")
+	}
+
+	var body *bytes.Buffer
+	if release != nil {
+		payload, err := json.Marshal(release)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(payload)
+	} else {
+		body = &bytes.Buffer{}
+	}
+
+	req, err := http.NewRequest(method, releaseURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", conf.GHToken))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return nil, fmt.Errorf("release request failed: %s", resp.Status)
+	}
+
+	var releaseResp ReleaseResponse
+	err = json.NewDecoder(resp.Body).Decode(&releaseResp)
+	if err != nil {
+		return nil, err
 	}
 
+	return &releaseResp, nil
+}
+
+// CreateRelease creates a new release. Whether it is published is controlled by release.Draft
+func CreateRelease(owner, repo string, release *Release, conf *config.Config) (*ReleaseResponse, error) {
 	releaseURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", owner, repo)
-	data := map[string]interface{}{
-		"title":      title,
-		"body":       body,
-		"tag_name":   tag,
-		"draft":      true,
-		"prerelease": false,
+	return releaseRequest(http.MethodPost, releaseURL, release, http.StatusCreated, conf)
+}
+
+// GetReleaseByTag gets the release for the given tag, including drafts. Returns ErrReleaseNotFound if there is none.
+func GetReleaseByTag(owner, repo, tag string, conf *config.Config) (*ReleaseResponse, error) {
+	if conf.GHToken == "" {
+		return nil, fmt.Errorf("no GitHub token found")
 	}
 
-	payload, err := json.Marshal(data)
+	// releases/tags/{tag} only finds published releases, so the releases are listed instead
+	client := &http.Client{}
+	for page := 1; ; page++ {
+		releasesURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases?per_page=%d&page=%d", owner, repo, releasesPerPage, page)
+		req, err := http.NewRequest(http.MethodGet, releasesURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", conf.GHToken))
+		req.Header.Set("Accept", "application/vnd.github+json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var releases []ReleaseResponse
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to list releases: %s", resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&releases)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range releases {
+			if releases[i].TagName == tag {
+				return &releases[i], nil
+			}
+		}
+
+		if len(releases) < releasesPerPage {
+			return nil, ErrReleaseNotFound
+		}
+	}
+}
+
+// UpdateRelease overwrites the release with the given ID
+func UpdateRelease(owner, repo string, id int, release *Release, conf *config.Config) (*ReleaseResponse, error) {
+	releaseURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/%d", owner, repo, id)
+	return releaseRequest(http.MethodPatch, releaseURL, release, http.StatusOK, conf)
+}
+
+// DeleteReleaseAsset deletes an asset from a release. Used to replace assets on update.
+func DeleteReleaseAsset(owner, repo string, assetID int, conf *config.Config) error {
+	if conf.GHToken == "" {
+		return fmt.Errorf("no GitHub token found")
+	}
+
+	assetURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/assets/%d", owner, repo, assetID)
+	req, err := http.NewRequest(http.MethodDelete, assetURL, nil)
 	if err != nil {
 		return err
 	}
 
-	releaseReq, err := http.NewRequest("POST", releaseURL, strings.NewReader(string(payload)))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", conf.GHToken))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to delete release asset: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// UploadReleaseAsset uploads the file at path to the release. uploadURL is the
+// upload_url returned by the GitHub API, which may still contain its URI template.
+func UploadReleaseAsset(uploadURL, path string, conf *config.Config) error {
+	if conf.GHToken == "" {
+		return fmt.Errorf("no GitHub token found")
+	}
+
+	// remove the {?name,label} template from the end of the URL
+	if i := strings.Index(uploadURL, "{"); i != -1 {
+		uploadURL = uploadURL[:i]
+	}
+
+	name := filepath.Base(path)
+	assetURL := fmt.Sprintf("%s?name=%s", uploadURL, url.QueryEscape(name))
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	req, err := http.NewRequest(http.MethodPost, assetURL, bytes.NewReader(contents))
 	if err != nil {
 		return err
 	}
 
-	releaseReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", conf.GHToken))
-	releaseReq.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", conf.GHToken))
+	req.Header.Set("Content-Type", contentType)
+	req.ContentLength = int64(len(contents))
 
 	client := &http.Client{}
-	releaseResp, err := client.Do(releaseReq)
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
-	defer releaseResp.Body.Close()
+	defer resp.Body.Close()
 
-	if releaseResp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to create release draft: %s", releaseResp.Status)
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to upload asset %s: %s", name, resp.Status)
 	}
 
 	return nil
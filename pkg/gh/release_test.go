@@ -0,0 +1,53 @@
+package gh
+
+import (
+	"testing"
+)
+
+func TestIsPrerelease(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		expected bool
+	}{
+		{
+			name:     "Stable tag",
+			tag:      "v1.2.3",
+			expected: false,
+		},
+		{
+			name:     "Stable tag without prefix",
+			tag:      "1.2.3",
+			expected: false,
+		},
+		{
+			name:     "Release candidate",
+			tag:      "v1.2.3-rc.1",
+			expected: true,
+		},
+		{
+			name:     "Beta without patch version",
+			tag:      "v2.0-beta",
+			expected: true,
+		},
+		{
+			name:     "Build metadata only",
+			tag:      "v1.2.3+20240218",
+			expected: false,
+		},
+		{
+			name:     "Non semver tag",
+			tag:      "nightly-build",
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := IsPrerelease(test.tag)
+			if result != test.expected {
+				t.Errorf("Expected %v for tag '%s', but got %v", test.expected, test.tag, result)
+			}
+		})
+	}
+}
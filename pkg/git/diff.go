@@ -1,6 +1,12 @@
 package git
 
-import "strings"
+import (
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
 
 func Diff() (string, error) {
 	return git("diff")
@@ -63,3 +69,130 @@ func Log() (string, error) {
 func LogBetween(base, head string) (string, error) {
 	return git("log", "--oneline", base+".."+head)
 }
+
+// the new side of a hunk header, e.g. "@@ -10,2 +12,3 @@"
+var hunkRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// TopLevel returns the absolute path to the root of the repository containing dir
+func TopLevel(dir string) (string, error) {
+	return gitIn(dir, "rev-parse", "--show-toplevel")
+}
+
+// ChangedFilesSince returns the files in the repository containing dir that changed between ref
+// and the working tree, including new files that aren't tracked yet.
+// Paths are relative to the root of the repository.
+func ChangedFilesSince(dir, ref string) ([]string, error) {
+	resp, err := gitIn(dir, "diff", "--name-only", ref)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitIn(dir, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(splitLines(resp), splitLines(untracked)...), nil
+}
+
+// StagedFiles returns the files in the repository containing dir that are staged for commit.
+// Paths are relative to the root of the repository.
+func StagedFiles(dir string) ([]string, error) {
+	resp, err := gitIn(dir, "diff", "--name-only", "--cached")
+	if err != nil {
+		return nil, err
+	}
+
+	return splitLines(resp), nil
+}
+
+// ChangedLinesSince returns the line ranges of the file that changed since ref. All of a file
+// that isn't tracked yet has changed.
+func ChangedLinesSince(ref, file string) ([][2]int, error) {
+	dir, name, err := fileDir(file)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitIn(dir, "ls-files", "--others", "--exclude-standard", "--", name)
+	if err != nil {
+		return nil, err
+	}
+	if untracked != "" {
+		return [][2]int{{1, math.MaxInt}}, nil
+	}
+
+	resp, err := gitIn(dir, "diff", "-U0", ref, "--", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseHunks(resp), nil
+}
+
+// StagedChangedLines returns the line ranges of the file that are staged for commit
+func StagedChangedLines(file string) ([][2]int, error) {
+	dir, name, err := fileDir(file)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := gitIn(dir, "diff", "-U0", "--cached", "--", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseHunks(resp), nil
+}
+
+// StagedContents returns the contents of the file staged for commit, which differ from the
+// working tree when the file has unstaged changes
+func StagedContents(file string) (string, error) {
+	dir, name, err := fileDir(file)
+	if err != nil {
+		return "", err
+	}
+	// :./name is the path in the index relative to the directory git runs in
+	return gitIn(dir, "show", ":./"+name)
+}
+
+// fileDir splits the file into its absolute directory, which git is run in, and its name
+func fileDir(file string) (string, string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Dir(abs), filepath.Base(abs), nil
+}
+
+// parses the hunk headers of a diff into the line ranges they cover in the new file.
+// Pure deletions are returned as a range covering the line after the deletion.
+func parseHunks(diff string) [][2]int {
+	var ranges [][2]int
+	for _, line := range strings.Split(diff, "\n") {
+		matches := hunkRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		start, _ := strconv.Atoi(matches[1])
+		count := 1
+		if matches[2] != "" {
+			count, _ = strconv.Atoi(matches[2])
+		}
+
+		if count == 0 {
+			ranges = append(ranges, [2]int{start, start + 1})
+			continue
+		}
+		ranges = append(ranges, [2]int{start, start + count - 1})
+	}
+	return ranges
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -15,3 +15,8 @@ func git(args ...string) (string, error) {
 
 	return strings.TrimSpace(string(out)), nil
 }
+
+// gitIn runs git in the directory instead of the current one
+func gitIn(dir string, args ...string) (string, error) {
+	return git(append([]string{"-C", dir}, args...)...)
+}
@@ -0,0 +1,290 @@
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// package for recording the files Otto changes so the changes can be undone
+
+const entryFile = "entry.json"
+
+// journalDir returns the directory the entries are kept in, creating it if needed.
+// Overridden in tests.
+var journalDir = func() (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(usr.HomeDir, ".ottodocs", "journal")
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// File is a file changed by a command
+type File struct {
+	// absolute path to the file
+	Path string `json:"path"`
+	// false if the command created the file
+	Existed bool `json:"existed"`
+	// name of the file in the entry directory holding the original contents
+	Original string `json:"original,omitempty"`
+	// hash of the contents the command wrote, used to tell if the file changed since
+	Hash string `json:"hash"`
+}
+
+// Entry is the record of the files changed by one command
+type Entry struct {
+	ID      string    `json:"id"`
+	Command string    `json:"command"`
+	WorkDir string    `json:"work_dir"`
+	Time    time.Time `json:"time"`
+	Files   []File    `json:"files"`
+	Undone  bool      `json:"undone"`
+
+	dir string
+}
+
+// NewEntry starts an entry for the command. Nothing is saved until the first file is recorded.
+func NewEntry(command string) *Entry {
+	wd, _ := os.Getwd()
+	return &Entry{
+		Command: command,
+		WorkDir: wd,
+	}
+}
+
+// Hash hashes file contents
+func Hash(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+// Record adds a file the command wrote to the entry and saves it. original is the
+// contents before the command changed it. Not safe for concurrent use.
+func (e *Entry) Record(path, original string, existed bool, contents string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if e.dir == "" {
+		err = e.create()
+		if err != nil {
+			return err
+		}
+	}
+
+	// a file written more than once keeps its first original
+	for i, file := range e.Files {
+		if file.Path == path {
+			e.Files[i].Hash = Hash(contents)
+			return e.save()
+		}
+	}
+
+	file := File{
+		Path:    path,
+		Existed: existed,
+		Hash:    Hash(contents),
+	}
+	if existed {
+		file.Original = strconv.Itoa(len(e.Files)) + ".orig"
+		err = os.WriteFile(filepath.Join(e.dir, file.Original), []byte(original), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	e.Files = append(e.Files, file)
+
+	return e.save()
+}
+
+// creates the entry directory, the ID is the time it was created
+func (e *Entry) create() error {
+	base, err := journalDir()
+	if err != nil {
+		return err
+	}
+
+	e.Time = time.Now()
+	id := e.Time.Format("20060102-150405")
+	for i := 2; ; i++ {
+		e.dir = filepath.Join(base, id)
+		err = os.Mkdir(e.dir, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		id = e.Time.Format("20060102-150405") + "-" + strconv.Itoa(i)
+	}
+	e.ID = id
+
+	return nil
+}
+
+func (e *Entry) save() error {
+	contents, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(e.dir, entryFile), contents, 0644)
+}
+
+// List returns the recorded entries, most recent first
+func List() ([]*Entry, error) {
+	base, err := journalDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := os.ReadDir(base)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entry, err := load(filepath.Join(base, dir.Name()))
+		if err != nil {
+			// skip anything that isn't an entry
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+
+	return entries, nil
+}
+
+// Get returns the entry with the ID
+func Get(id string) (*Entry, error) {
+	base, err := journalDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := load(filepath.Join(base, filepath.Base(id)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no journal entry with the ID %s", id)
+	}
+	return entry, err
+}
+
+// Latest returns the most recent entry that hasn't been undone
+func Latest() (*Entry, error) {
+	entries, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.Undone {
+			return entry, nil
+		}
+	}
+	return nil, errors.New("there is nothing to undo")
+}
+
+func load(dir string) (*Entry, error) {
+	contents, err := os.ReadFile(filepath.Join(dir, entryFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	err = json.Unmarshal(contents, &entry)
+	if err != nil {
+		return nil, err
+	}
+	entry.dir = dir
+
+	return &entry, nil
+}
+
+// Changed returns the files that were changed or deleted after the command wrote them
+func (e *Entry) Changed() ([]string, error) {
+	var changed []string
+	for _, file := range e.Files {
+		contents, err := os.ReadFile(file.Path)
+		if os.IsNotExist(err) {
+			changed = append(changed, file.Path)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if Hash(string(contents)) != file.Hash {
+			changed = append(changed, file.Path)
+		}
+	}
+	return changed, nil
+}
+
+// Restore puts the files back the way they were before the command changed them, deleting the ones
+// it created. write is used to write the original contents. Files changed after the command wrote them
+// are only restored if force is true.
+func (e *Entry) Restore(force bool, write func(path, contents string) error) error {
+	if e.Undone && !force {
+		return fmt.Errorf("%s has already been undone", e.ID)
+	}
+
+	if !force {
+		changed, err := e.Changed()
+		if err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			return &ChangedError{Files: changed}
+		}
+	}
+
+	for _, file := range e.Files {
+		if !file.Existed {
+			err := os.Remove(file.Path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		original, err := os.ReadFile(filepath.Join(e.dir, file.Original))
+		if err != nil {
+			return fmt.Errorf("could not load the original of %s: %s", file.Path, err)
+		}
+		err = write(file.Path, string(original))
+		if err != nil {
+			return err
+		}
+	}
+
+	e.Undone = true
+	return e.save()
+}
+
+// ChangedError is returned by Restore when files were changed after the command wrote them
+type ChangedError struct {
+	Files []string
+}
+
+func (e *ChangedError) Error() string {
+	return fmt.Sprintf("%d files changed after they were written", len(e.Files))
+}
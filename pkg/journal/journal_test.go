@@ -0,0 +1,68 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestore(t *testing.T) {
+	base := t.TempDir()
+	journalDir = func() (string, error) { return base, nil }
+
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.txt")
+	created := filepath.Join(dir, "created.txt")
+
+	write := func(path, contents string) error {
+		return os.WriteFile(path, []byte(contents), 0644)
+	}
+
+	entry := NewEntry("otto edit")
+	if err := write(edited, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Record(edited, "old", true, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := write(created, "created"); err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Record(created, "", false, "created"); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.ID != entry.ID || len(latest.Files) != 2 {
+		t.Fatalf("Expected the latest entry to be %s with 2 files, but got %s with %d", entry.ID, latest.ID, len(latest.Files))
+	}
+
+	// a file changed after it was written blocks the undo
+	if err := write(edited, "changed by hand"); err != nil {
+		t.Fatal(err)
+	}
+	var changedErr *ChangedError
+	if err := latest.Restore(false, write); !errors.As(err, &changedErr) {
+		t.Fatalf("Expected a ChangedError, but got %v", err)
+	}
+
+	if err := latest.Restore(true, write); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, _ := os.ReadFile(edited)
+	if string(contents) != "old" {
+		t.Errorf("Expected the original contents to be restored, but got %q", contents)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("Expected the created file to be deleted")
+	}
+
+	if _, err := Latest(); err == nil {
+		t.Errorf("Expected nothing left to undo")
+	}
+}
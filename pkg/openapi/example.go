@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+)
+
+// the deepest schemas are followed when making examples, in case they refer to themselves
+const maxExampleDepth = 8
+
+// Resolve follows the reference of the schema to the component it refers to
+func (d *Document) Resolve(schema *Schema) *Schema {
+	for i := 0; schema != nil && schema.Ref != "" && i < maxExampleDepth; i++ {
+		name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		if !ok || d.Components == nil {
+			return nil
+		}
+		schema = d.Components.Schemas[name]
+	}
+	return schema
+}
+
+// TypeName returns the type of the schema, the first that isn't null if there are several
+func (s *Schema) TypeName() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	if len(s.Properties) > 0 {
+		return "object"
+	}
+	if s.Items != nil {
+		return "array"
+	}
+	return ""
+}
+
+// Example returns an example value for the schema, using the examples, defaults and enums it
+// documents where possible
+func (d *Document) Example(schema *Schema) any {
+	return d.example(schema, 0)
+}
+
+func (d *Document) example(schema *Schema, depth int) any {
+	schema = d.Resolve(schema)
+	if schema == nil || depth > maxExampleDepth {
+		return nil
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if schema.Default != nil {
+		return schema.Default
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+
+	switch schema.TypeName() {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	case "array":
+		if item := d.example(schema.Items, depth+1); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "object":
+		obj := map[string]any{}
+		for name, property := range schema.Properties {
+			obj[name] = d.example(property, depth+1)
+		}
+		return obj
+	}
+	return nil
+}
+
+// ParameterExample returns the example value of the parameter, formatted for a URL or header
+func (d *Document) ParameterExample(param *Parameter) string {
+	value := d.Example(param.Schema)
+	// ids are more often numbers than the word string
+	if param.In == "path" && (param.Schema == nil || value == "string") {
+		return "1"
+	}
+	switch v := value.(type) {
+	case nil:
+		return "1"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// SchemaFromExample describes the shape of an example value
+func SchemaFromExample(value any) *Schema {
+	switch v := value.(type) {
+	case nil:
+		return &Schema{Type: "null"}
+	case bool:
+		return &Schema{Type: "boolean"}
+	case float64:
+		if v == float64(int64(v)) {
+			return &Schema{Type: "integer"}
+		}
+		return &Schema{Type: "number"}
+	case int, int64:
+		return &Schema{Type: "integer"}
+	case string:
+		return &Schema{Type: "string"}
+	case []any:
+		schema := &Schema{Type: "array"}
+		if len(v) > 0 {
+			schema.Items = SchemaFromExample(v[0])
+		}
+		return schema
+	case map[string]any:
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for name, property := range v {
+			schema.Properties[name] = SchemaFromExample(property)
+		}
+		return schema
+	}
+	return &Schema{}
+}
+
+// CheckValue compares a decoded JSON value to the schema. Returns the differences, each
+// prefixed with where in the value it is, for example "body.user.name".
+func (d *Document) CheckValue(schema *Schema, value any, where string) []string {
+	return d.checkValue(schema, value, where, 0)
+}
+
+func (d *Document) checkValue(schema *Schema, value any, where string, depth int) []string {
+	schema = d.Resolve(schema)
+	if schema == nil || depth > maxExampleDepth {
+		return nil
+	}
+
+	if value == nil {
+		if schema.allows("null") || schema.Type == nil {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s is null, but is documented as %s", where, schema.TypeName())}
+	}
+
+	actual := jsonType(value)
+	expected := schema.TypeName()
+	if expected == "" {
+		return nil
+	}
+	if actual != expected && !(actual == "integer" && expected == "number") && !schema.allows(actual) {
+		return []string{fmt.Sprintf("%s is %s, but is documented as %s", where, article(actual), expected)}
+	}
+
+	var problems []string
+	switch v := value.(type) {
+	case []any:
+		for i, item := range v {
+			problems = append(problems, d.checkValue(schema.Items, item, fmt.Sprintf("%s[%d]", where, i), depth+1)...)
+			// the items are usually alike, don't report the same problem for each
+			if len(problems) > 0 {
+				break
+			}
+		}
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s is required, but is missing", where, name))
+			}
+		}
+
+		var names []string
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := schema.Properties[name]
+			if !ok {
+				if len(schema.Properties) > 0 {
+					problems = append(problems, fmt.Sprintf("%s.%s is not documented", where, name))
+				}
+				continue
+			}
+			problems = append(problems, d.checkValue(property, v[name], where+"."+name, depth+1)...)
+		}
+
+		var documented []string
+		for name := range schema.Properties {
+			documented = append(documented, name)
+		}
+		sort.Strings(documented)
+		for _, name := range documented {
+			if _, ok := v[name]; !ok && !utils.Contains(schema.Required, name) {
+				problems = append(problems, fmt.Sprintf("%s.%s is documented, but is missing", where, name))
+			}
+		}
+	}
+	return problems
+}
+
+// allows reports whether the type is one of the types of the schema
+func (s *Schema) allows(name string) bool {
+	switch t := s.Type.(type) {
+	case string:
+		return t == name
+	case []any:
+		for _, item := range t {
+			if item == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "null"
+}
+
+func article(typeName string) string {
+	if strings.ContainsAny(typeName[:1], "aeiou") {
+		return "an " + typeName
+	}
+	return "a " + typeName
+}
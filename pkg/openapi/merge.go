@@ -0,0 +1,152 @@
+package openapi
+
+// Merge updates an existing document with a generated one without losing what was written by
+// hand. Generated operations replace the existing ones, but the existing summaries, descriptions,
+// operation IDs, tags and fields Otto doesn't generate, like security or oneOf, are kept wherever
+// they are set, as are the parameters, responses and request bodies that weren't generated.
+// Operations and component schemas that
+// weren't generated are left as they are, as is the info of the existing document.
+func Merge(existing, generated *Document) *Document {
+	if existing.Paths == nil {
+		existing.Paths = map[string]*PathItem{}
+	}
+
+	for _, path := range generated.SortedPaths() {
+		item := generated.Paths[path]
+		oldItem, ok := existing.Paths[path]
+		if !ok || oldItem == nil {
+			existing.Paths[path] = item
+			continue
+		}
+		for _, method := range Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			if old := oldItem.Operation(method); old != nil {
+				mergeOperation(old, op)
+			}
+			oldItem.SetOperation(method, op)
+		}
+	}
+
+	if generated.Components != nil {
+		if existing.Components == nil {
+			existing.Components = &Components{}
+		}
+		if existing.Components.Schemas == nil {
+			existing.Components.Schemas = map[string]*Schema{}
+		}
+		for name, schema := range generated.Components.Schemas {
+			if old, ok := existing.Components.Schemas[name]; ok {
+				mergeSchema(old, schema)
+			}
+			existing.Components.Schemas[name] = schema
+		}
+	}
+
+	return existing
+}
+
+// keep returns the old text if it was set, otherwise the new one
+func keep(old, new string) string {
+	if old != "" {
+		return old
+	}
+	return new
+}
+
+// keepExtra adds the old fields Otto doesn't generate to the new ones
+func keepExtra(old, new map[string]any) map[string]any {
+	for key, value := range old {
+		if new == nil {
+			new = map[string]any{}
+		}
+		if _, ok := new[key]; !ok {
+			new[key] = value
+		}
+	}
+	return new
+}
+
+// mergeOperation copies the hand written parts of the old operation to the new one
+func mergeOperation(old, op *Operation) {
+	op.Extra = keepExtra(old.Extra, op.Extra)
+	op.Summary = keep(old.Summary, op.Summary)
+	op.Description = keep(old.Description, op.Description)
+	op.OperationID = keep(old.OperationID, op.OperationID)
+	if len(old.Tags) > 0 {
+		op.Tags = old.Tags
+	}
+
+	oldParams := map[string]*Parameter{}
+	for _, param := range old.Parameters {
+		if param != nil {
+			oldParams[param.In+":"+param.Name] = param
+		}
+	}
+	for _, param := range op.Parameters {
+		if param == nil {
+			continue
+		}
+		if oldParam, ok := oldParams[param.In+":"+param.Name]; ok {
+			param.Extra = keepExtra(oldParam.Extra, param.Extra)
+			param.Description = keep(oldParam.Description, param.Description)
+			mergeSchema(oldParam.Schema, param.Schema)
+			delete(oldParams, param.In+":"+param.Name)
+		}
+	}
+	for _, param := range old.Parameters {
+		if param != nil && oldParams[param.In+":"+param.Name] == param {
+			op.Parameters = append(op.Parameters, param)
+		}
+	}
+
+	if op.RequestBody == nil {
+		op.RequestBody = old.RequestBody
+	} else if old.RequestBody != nil {
+		op.RequestBody.Extra = keepExtra(old.RequestBody.Extra, op.RequestBody.Extra)
+		op.RequestBody.Description = keep(old.RequestBody.Description, op.RequestBody.Description)
+		mergeContent(old.RequestBody.Content, op.RequestBody.Content)
+	}
+
+	for status, response := range op.Responses {
+		oldResponse, ok := old.Responses[status]
+		if !ok || oldResponse == nil || response == nil {
+			continue
+		}
+		response.Extra = keepExtra(oldResponse.Extra, response.Extra)
+		response.Description = keep(oldResponse.Description, response.Description)
+		mergeContent(oldResponse.Content, response.Content)
+	}
+	for status, oldResponse := range old.Responses {
+		if _, ok := op.Responses[status]; !ok {
+			if op.Responses == nil {
+				op.Responses = map[string]*Response{}
+			}
+			op.Responses[status] = oldResponse
+		}
+	}
+}
+
+func mergeContent(old, content map[string]*MediaType) {
+	for mediaType, media := range content {
+		if oldMedia, ok := old[mediaType]; ok && oldMedia != nil && media != nil {
+			media.Extra = keepExtra(oldMedia.Extra, media.Extra)
+			mergeSchema(oldMedia.Schema, media.Schema)
+		}
+	}
+}
+
+// mergeSchema copies the descriptions of the old schema and its properties to the new one
+func mergeSchema(old, schema *Schema) {
+	if old == nil || schema == nil {
+		return
+	}
+	schema.Extra = keepExtra(old.Extra, schema.Extra)
+	schema.Description = keep(old.Description, schema.Description)
+	for name, property := range schema.Properties {
+		mergeSchema(old.Properties[name], property)
+	}
+	mergeSchema(old.Items, schema.Items)
+}
@@ -0,0 +1,407 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// package for OpenAPI 3.1 documents. Only the parts of the specification Otto generates are
+// modeled, see https://spec.openapis.org/oas/v3.1.0. Everything else, like security schemes,
+// oneOf or x- extensions, is kept in the Extra field of the object it's in so documents
+// written by hand aren't changed when they are read and written again.
+
+// Version is the version of the specification documents are written for
+const Version = "3.1.0"
+
+// Methods are the HTTP methods an operation can be defined for, in the order they are written
+var Methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi" yaml:"openapi"`
+	Info       Info                 `json:"info" yaml:"info"`
+	Servers    []Server             `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths" yaml:"paths"`
+	Components *Components          `json:"components,omitempty" yaml:"components,omitempty"`
+	Extra      map[string]any       `json:"-" yaml:",inline"`
+}
+
+// Info is the metadata of the API
+type Info struct {
+	Title       string         `json:"title" yaml:"title"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string         `json:"version" yaml:"version"`
+	Extra       map[string]any `json:"-" yaml:",inline"`
+}
+
+// Server is a URL the API is served from
+type Server struct {
+	URL         string         `json:"url" yaml:"url"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Extra       map[string]any `json:"-" yaml:",inline"`
+}
+
+// PathItem is the operations available on a path
+type PathItem struct {
+	Summary     string         `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Get         *Operation     `json:"get,omitempty" yaml:"get,omitempty"`
+	Put         *Operation     `json:"put,omitempty" yaml:"put,omitempty"`
+	Post        *Operation     `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete      *Operation     `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Options     *Operation     `json:"options,omitempty" yaml:"options,omitempty"`
+	Head        *Operation     `json:"head,omitempty" yaml:"head,omitempty"`
+	Patch       *Operation     `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Trace       *Operation     `json:"trace,omitempty" yaml:"trace,omitempty"`
+	Parameters  []*Parameter   `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Extra       map[string]any `json:"-" yaml:",inline"`
+}
+
+// Operation is a single method on a path
+type Operation struct {
+	Summary     string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Tags        []string             `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses" yaml:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Extra       map[string]any       `json:"-" yaml:",inline"`
+}
+
+// Parameter is a path, query, header or cookie parameter
+type Parameter struct {
+	Name        string         `json:"name" yaml:"name"`
+	In          string         `json:"in" yaml:"in"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *Schema        `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Extra       map[string]any `json:"-" yaml:",inline"`
+}
+
+// RequestBody is the body of a request by its media type
+type RequestBody struct {
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content" yaml:"content"`
+	Extra       map[string]any        `json:"-" yaml:",inline"`
+}
+
+// Response is a response by its media type
+type Response struct {
+	Description string                `json:"description" yaml:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+	Extra       map[string]any        `json:"-" yaml:",inline"`
+}
+
+// MediaType is the schema of a body in one media type
+type MediaType struct {
+	Schema  *Schema        `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Example any            `json:"example,omitempty" yaml:"example,omitempty"`
+	Extra   map[string]any `json:"-" yaml:",inline"`
+}
+
+// Components are the reusable parts of the document
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	Extra   map[string]any     `json:"-" yaml:",inline"`
+}
+
+// Schema is a JSON Schema. Type is either a type name or a list of them.
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type        any                `json:"type,omitempty" yaml:"type,omitempty"`
+	Format      string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Items       *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Required    []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Enum        []any              `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default     any                `json:"default,omitempty" yaml:"default,omitempty"`
+	Example     any                `json:"example,omitempty" yaml:"example,omitempty"`
+	Extra       map[string]any     `json:"-" yaml:",inline"`
+}
+
+// New creates an empty document
+func New(title, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]*PathItem{},
+	}
+}
+
+// field returns the field of the path item that holds the operation for the method
+func (p *PathItem) field(method string) **Operation {
+	switch strings.ToLower(method) {
+	case "get":
+		return &p.Get
+	case "put":
+		return &p.Put
+	case "post":
+		return &p.Post
+	case "delete":
+		return &p.Delete
+	case "options":
+		return &p.Options
+	case "head":
+		return &p.Head
+	case "patch":
+		return &p.Patch
+	case "trace":
+		return &p.Trace
+	}
+	return nil
+}
+
+// Operation returns the operation for the method, nil if there is none
+func (p *PathItem) Operation(method string) *Operation {
+	field := p.field(method)
+	if field == nil {
+		return nil
+	}
+	return *field
+}
+
+// SetOperation sets the operation for the method
+func (p *PathItem) SetOperation(method string, op *Operation) error {
+	field := p.field(method)
+	if field == nil {
+		return fmt.Errorf("unsupported method %s", method)
+	}
+	*field = op
+	return nil
+}
+
+// SetOperation sets the operation for the method on the path, adding the path if needed
+func (d *Document) SetOperation(method, path string, op *Operation) error {
+	if d.Paths == nil {
+		d.Paths = map[string]*PathItem{}
+	}
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	return item.SetOperation(method, op)
+}
+
+// SortedPaths returns the paths of the document, sorted
+func (d *Document) SortedPaths() []string {
+	var paths []string
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+var endpointParamRegexes = []*regexp.Regexp{
+	// flask style <id> and <int:id>
+	regexp.MustCompile(`<(?:\w+:)?(\w+)>`),
+	// chi style {id:[0-9]+}
+	regexp.MustCompile(`\{(\w+):[^}]*\}`),
+	// express, gin, echo, fiber and chi style :id
+	regexp.MustCompile(`(?:^|/):(\w+)`),
+}
+
+// ParseEndpoint splits an endpoint such as "GET /users/:id" into its lowercase method and
+// its path, with the path parameters written as OpenAPI templates, for example /users/{id}
+func ParseEndpoint(endpoint string) (string, string, error) {
+	fields := strings.Fields(endpoint)
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("the endpoint %q must be a method and a path", endpoint)
+	}
+
+	method := strings.ToLower(fields[0])
+	if (&PathItem{}).field(method) == nil {
+		return "", "", fmt.Errorf("the endpoint %q has an unsupported method", endpoint)
+	}
+
+	path := fields[1]
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	for _, re := range endpointParamRegexes {
+		path = re.ReplaceAllStringFunc(path, func(match string) string {
+			prefix := ""
+			if strings.HasPrefix(match, "/") {
+				prefix = "/"
+			}
+			return prefix + "{" + re.FindStringSubmatch(match)[1] + "}"
+		})
+	}
+	return method, path, nil
+}
+
+var templateRegex = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// PathParameters returns the names of the templated parameters of the path
+func PathParameters(path string) []string {
+	var names []string
+	for _, match := range templateRegex.FindAllStringSubmatch(path, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// FillPathParameters declares the templated parameters of the path the operation doesn't
+// declare, and marks all path parameters as required, as the specification requires
+func FillPathParameters(path string, op *Operation) {
+	declared := map[string]bool{}
+	for _, param := range op.Parameters {
+		if param != nil && param.In == "path" {
+			param.Required = true
+			declared[param.Name] = true
+		}
+	}
+	for _, name := range PathParameters(path) {
+		if !declared[name] {
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+}
+
+// IsJSON reports whether the document at the path is written as JSON rather than YAML
+func IsJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// Load reads a JSON or YAML document
+func Load(path string) (*Document, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(contents)
+}
+
+// Parse parses a JSON or YAML document
+func Parse(contents []byte) (*Document, error) {
+	var doc Document
+	// JSON is valid YAML, so one parser reads both
+	err := yaml.Unmarshal(contents, &doc)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Paths == nil {
+		doc.Paths = map[string]*PathItem{}
+	}
+	return &doc, nil
+}
+
+// Encode writes the document as JSON or YAML
+func Encode(doc *Document, asJSON bool) (string, error) {
+	if asJSON {
+		// the extra fields are only inlined by the YAML encoder, so JSON is written from its output
+		var node yaml.Node
+		err := node.Encode(doc)
+		if err != nil {
+			return "", err
+		}
+		var b bytes.Buffer
+		err = writeJSON(&b, &node, "")
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("\n")
+		return b.String(), nil
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	err := enc.Encode(doc)
+	if err != nil {
+		return "", err
+	}
+	err = enc.Close()
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// writeJSON writes the YAML node as indented JSON, keeping the order of the keys
+func writeJSON(b *bytes.Buffer, node *yaml.Node, indent string) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			b.WriteString("null")
+			return nil
+		}
+		return writeJSON(b, node.Content[0], indent)
+	case yaml.AliasNode:
+		return writeJSON(b, node.Alias, indent)
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		b.WriteString("{\n")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			b.WriteString(indent + "  ")
+			b.Write(key)
+			b.WriteString(": ")
+			err = writeJSON(b, node.Content[i+1], indent+"  ")
+			if err != nil {
+				return err
+			}
+			if i+2 < len(node.Content) {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+		return nil
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[\n")
+		for i, item := range node.Content {
+			b.WriteString(indent + "  ")
+			err := writeJSON(b, item, indent+"  ")
+			if err != nil {
+				return err
+			}
+			if i+1 < len(node.Content) {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "]")
+		return nil
+	}
+
+	var value any = node.Value
+	switch node.ShortTag() {
+	case "!!null":
+		value = nil
+	case "!!bool", "!!int", "!!float":
+		err := node.Decode(&value)
+		if err != nil {
+			return err
+		}
+	}
+	contents, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	b.Write(contents)
+	return nil
+}
@@ -0,0 +1,267 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"GET /users/:id":              "get /users/{id}",
+		"post users/<int:id>/posts":   "post /users/{id}/posts",
+		"DELETE /items/{id:[0-9]+}":   "delete /items/{id}",
+		"PATCH /teams/{team}/members": "patch /teams/{team}/members",
+	}
+	for endpoint, expected := range tests {
+		method, path, err := ParseEndpoint(endpoint)
+		if err != nil {
+			t.Errorf("Error parsing %s: %s", endpoint, err)
+			continue
+		}
+		if method+" "+path != expected {
+			t.Errorf("Expected %s to parse to %s, but got %s %s", endpoint, expected, method, path)
+		}
+	}
+
+	if _, _, err := ParseEndpoint("FETCH /users"); err == nil {
+		t.Error("Expected an error for an unsupported method")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc := New("API", "1.0.0")
+	op := &Operation{
+		Parameters: []*Parameter{{Name: "limit", In: "body", Schema: &Schema{Type: "int"}}},
+		Responses:  map[string]*Response{"200": {}, "ok": {Description: "OK"}},
+	}
+	doc.SetOperation("get", "/users/{id}", op)
+
+	err := Validate(doc)
+	if err == nil {
+		t.Fatal("Expected the document to be invalid")
+	}
+	for _, problem := range []string{
+		"the parameter limit must be in query, header, path or cookie",
+		"parameter limit: unknown type \"int\"",
+		"the path parameter id is not declared",
+		"the 200 response must have a description",
+		"the response status \"ok\"",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the problems to include %q, but got:\n%s", problem, err)
+		}
+	}
+
+	op.Parameters[0].In = "query"
+	op.Parameters[0].Schema.Type = "integer"
+	op.Responses = map[string]*Response{"200": {Description: "The user"}}
+	FillPathParameters("/users/{id}", op)
+	if err := Validate(doc); err != nil {
+		t.Errorf("Expected the document to be valid, but got %s", err)
+	}
+
+	// the model can send empty parameters, which are reported rather than crashing
+	op.Parameters = append(op.Parameters, nil)
+	FillPathParameters("/users/{id}", op)
+	Merge(doc, doc)
+	if err := Validate(doc); err == nil || !strings.Contains(err.Error(), "a parameter is empty") {
+		t.Errorf("Expected the empty parameter to be reported, but got %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	existing, err := Parse([]byte(`openapi: 3.1.0
+info:
+  title: Hand written
+  version: 2.0.0
+paths:
+  /users:
+    get:
+      summary: List the users
+      operationId: listUsers
+      parameters:
+        - name: X-Api-Key
+          in: header
+          required: true
+          schema:
+            type: string
+      responses:
+        "403":
+          description: The key can't list users
+        "200":
+          description: Every user, newest first
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    name:
+                      type: string
+                      description: The display name
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+  /legacy:
+    get:
+      responses:
+        "410":
+          description: Gone
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated := New("Generated", "1.0.0")
+	generated.SetOperation("get", "/users", &Operation{
+		Summary:     "Get users",
+		OperationID: "getUsers",
+		Responses: map[string]*Response{
+			"200": {Description: "The users", Content: map[string]*MediaType{
+				"application/json": {Schema: &Schema{Type: "array", Items: &Schema{Type: "object", Properties: map[string]*Schema{
+					"name":  {Type: "string", Description: "The name"},
+					"email": {Type: "string", Description: "The email address"},
+				}}}},
+			}},
+			"401": {Description: "Not signed in"},
+		},
+	})
+	generated.SetOperation("post", "/users", &Operation{Responses: map[string]*Response{"201": {Description: "Created"}}})
+
+	merged := Merge(existing, generated)
+
+	if merged.Info.Title != "Hand written" || merged.Paths["/legacy"] == nil {
+		t.Error("Expected the existing info and operations to be kept")
+	}
+	users := merged.Paths["/users"]
+	get := users.Get
+	if get.Summary != "List the users" || get.OperationID != "listUsers" {
+		t.Errorf("Expected the hand written summary and operation ID to be kept, but got %q and %q", get.Summary, get.OperationID)
+	}
+	if get.Responses["200"].Description != "Every user, newest first" || get.Responses["401"] == nil {
+		t.Error("Expected the hand written response description to be kept and the new response added")
+	}
+	properties := get.Responses["200"].Content["application/json"].Schema.Items.Properties
+	if properties["name"].Description != "The display name" || properties["email"].Description != "The email address" {
+		t.Error("Expected the hand written property description to be kept and the new property added")
+	}
+	if get.Responses["403"] == nil || len(get.Parameters) != 1 || get.Parameters[0].Name != "X-Api-Key" {
+		t.Error("Expected the hand written response and parameter to be kept")
+	}
+	if users.Post == nil || users.Post.Responses["201"] == nil {
+		t.Error("Expected the new operation to be added")
+	} else if users.Post.RequestBody == nil {
+		t.Error("Expected the hand written request body to be kept")
+	}
+
+	if err := Validate(merged); err != nil {
+		t.Fatal(err)
+	}
+	for _, asJSON := range []bool{false, true} {
+		encoded, err := Encode(merged, asJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := Parse([]byte(encoded))
+		if err != nil {
+			t.Fatalf("Error parsing the encoded document: %s\n%s", err, encoded)
+		}
+		if parsed.Paths["/users"].Get.Summary != "List the users" {
+			t.Errorf("Expected the document to survive encoding, but got:\n%s", encoded)
+		}
+	}
+}
+
+func TestMergeKeepsUnmodeledFields(t *testing.T) {
+	handWritten := `openapi: 3.1.0
+info:
+  title: Hand written
+  version: 2.0.0
+  x-logo: logo.png
+security:
+  - bearer: []
+tags:
+  - name: users
+    description: User accounts
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      security:
+        - bearer: []
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+            minimum: 1
+      responses:
+        "200":
+          description: The user
+          content:
+            application/json:
+              schema:
+                oneOf:
+                  - $ref: '#/components/schemas/User'
+                  - type: "null"
+components:
+  securitySchemes:
+    bearer:
+      type: http
+      scheme: bearer
+  schemas:
+    User:
+      type: object
+      additionalProperties: false
+      properties:
+        age:
+          type: integer
+          minimum: 0
+`
+	var want map[string]any
+	if err := yaml.Unmarshal([]byte(handWritten), &want); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, asJSON := range []bool{false, true} {
+		existing, err := Parse([]byte(handWritten))
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := Encode(Merge(existing, New("Generated", "1.0.0")), asJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]any
+		if err := yaml.Unmarshal([]byte(encoded), &got); err != nil {
+			t.Fatalf("Error parsing the encoded document: %s\n%s", err, encoded)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected the hand written document to be unchanged, but got:\n%s", encoded)
+		}
+	}
+
+	// hand written fields of a generated operation are kept
+	existing, err := Parse([]byte(handWritten))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated := New("Generated", "1.0.0")
+	generated.SetOperation("get", "/users/{id}", &Operation{
+		Parameters: []*Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer"}}},
+		Responses:  map[string]*Response{"200": {Description: "OK"}},
+	})
+	get := Merge(existing, generated).Paths["/users/{id}"].Get
+	if get.Extra["security"] == nil || get.Parameters[0].Schema.Extra["minimum"] != 1 {
+		t.Errorf("Expected the security and minimum to be kept, but got %+v", get)
+	}
+}
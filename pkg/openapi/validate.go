@@ -0,0 +1,256 @@
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+)
+
+// ValidationError lists the ways a document doesn't follow the specification
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid OpenAPI document:\n- " + strings.Join(e.Problems, "\n- ")
+}
+
+var versionRegex = regexp.MustCompile(`^3\.1\.\d+$`)
+var statusRegex = regexp.MustCompile(`^(default|[1-5](\d\d|XX))$`)
+
+var parameterLocations = []string{"query", "header", "path", "cookie"}
+var schemaTypes = []string{"string", "number", "integer", "boolean", "array", "object", "null"}
+
+// Validate checks the document against the rules of the specification that apply to the parts
+// Otto generates. Returns a *ValidationError listing every problem, or nil if there are none.
+func Validate(doc *Document) error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !versionRegex.MatchString(doc.OpenAPI) {
+		add("openapi must be a 3.1 version, not %q", doc.OpenAPI)
+	}
+	if doc.Info.Title == "" {
+		add("info.title is required")
+	}
+	if doc.Info.Version == "" {
+		add("info.version is required")
+	}
+
+	operationIDs := map[string]string{}
+	for _, path := range doc.SortedPaths() {
+		item := doc.Paths[path]
+		if !strings.HasPrefix(path, "/") {
+			add("the path %s must start with /", path)
+		}
+		if item == nil {
+			add("the path %s is empty", path)
+			continue
+		}
+
+		for _, method := range Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			where := strings.ToUpper(method) + " " + path
+			for _, problem := range ValidateOperation(method, path, op, item.Parameters) {
+				add("%s: %s", where, problem)
+			}
+			if op.OperationID != "" {
+				if other, ok := operationIDs[op.OperationID]; ok {
+					add("%s: the operationId %s is already used by %s", where, op.OperationID, other)
+				}
+				operationIDs[op.OperationID] = where
+			}
+			for _, problem := range refProblems(doc, op) {
+				add("%s: %s", where, problem)
+			}
+		}
+	}
+
+	if doc.Components != nil {
+		for name, schema := range doc.Components.Schemas {
+			for _, problem := range schemaProblems(schema) {
+				add("components.schemas.%s: %s", name, problem)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidateOperation returns the problems with the operation on the path. shared are the
+// parameters declared for every operation on the path.
+func ValidateOperation(method, path string, op *Operation, shared []*Parameter) []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	templated := map[string]bool{}
+	for _, name := range PathParameters(path) {
+		templated[name] = true
+	}
+
+	declared := map[string]bool{}
+	seen := map[string]bool{}
+	for _, param := range append(append([]*Parameter{}, shared...), op.Parameters...) {
+		if param == nil {
+			add("a parameter is empty")
+			continue
+		}
+		if param.Name == "" {
+			add("a %s parameter has no name", param.In)
+		}
+		if !utils.Contains(parameterLocations, param.In) {
+			add("the parameter %s must be in query, header, path or cookie, not %q", param.Name, param.In)
+		}
+		key := param.In + ":" + param.Name
+		if seen[key] {
+			add("the %s parameter %s is declared more than once", param.In, param.Name)
+		}
+		seen[key] = true
+
+		if param.In == "path" {
+			declared[param.Name] = true
+			if !templated[param.Name] {
+				add("the path parameter %s is not in the path", param.Name)
+			}
+			if !param.Required {
+				add("the path parameter %s must be required", param.Name)
+			}
+		}
+		for _, problem := range schemaProblems(param.Schema) {
+			add("parameter %s: %s", param.Name, problem)
+		}
+	}
+	for name := range templated {
+		if !declared[name] {
+			add("the path parameter %s is not declared", name)
+		}
+	}
+
+	if op.RequestBody != nil {
+		if len(op.RequestBody.Content) == 0 {
+			add("the request body has no content")
+		}
+		for mediaType, content := range op.RequestBody.Content {
+			if content == nil {
+				continue
+			}
+			for _, problem := range schemaProblems(content.Schema) {
+				add("request body %s: %s", mediaType, problem)
+			}
+		}
+	}
+
+	if len(op.Responses) == 0 {
+		add("at least one response is required")
+	}
+	for status, response := range op.Responses {
+		if !statusRegex.MatchString(status) {
+			add("the response status %q must be default, an HTTP status code or a range like 2XX", status)
+		}
+		if response == nil || response.Description == "" {
+			add("the %s response must have a description", status)
+			continue
+		}
+		for mediaType, content := range response.Content {
+			if content == nil {
+				continue
+			}
+			for _, problem := range schemaProblems(content.Schema) {
+				add("%s response %s: %s", status, mediaType, problem)
+			}
+		}
+	}
+
+	return problems
+}
+
+// schemaProblems returns the problems with the schema and the schemas in it
+func schemaProblems(schema *Schema) []string {
+	if schema == nil {
+		return nil
+	}
+
+	var problems []string
+	switch t := schema.Type.(type) {
+	case nil:
+	case string:
+		if !utils.Contains(schemaTypes, t) {
+			problems = append(problems, fmt.Sprintf("unknown type %q", t))
+		}
+	case []any:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok || !utils.Contains(schemaTypes, name) {
+				problems = append(problems, fmt.Sprintf("unknown type %v", item))
+			}
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("the type must be a name or a list of names, not %v", t))
+	}
+
+	for name, property := range schema.Properties {
+		for _, problem := range schemaProblems(property) {
+			problems = append(problems, fmt.Sprintf("property %s: %s", name, problem))
+		}
+	}
+	for _, problem := range schemaProblems(schema.Items) {
+		problems = append(problems, "items: "+problem)
+	}
+	return problems
+}
+
+// refProblems returns the references in the operation to component schemas that don't exist
+func refProblems(doc *Document, op *Operation) []string {
+	var problems []string
+	var check func(schema *Schema)
+	check = func(schema *Schema) {
+		if schema == nil {
+			return
+		}
+		if name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/"); ok {
+			if doc.Components == nil || doc.Components.Schemas[name] == nil {
+				problems = append(problems, fmt.Sprintf("the schema %s is referenced but not defined", name))
+			}
+		}
+		for _, property := range schema.Properties {
+			check(property)
+		}
+		check(schema.Items)
+	}
+
+	for _, param := range op.Parameters {
+		if param != nil {
+			check(param.Schema)
+		}
+	}
+	if op.RequestBody != nil {
+		for _, content := range op.RequestBody.Content {
+			if content != nil {
+				check(content.Schema)
+			}
+		}
+	}
+	for _, response := range op.Responses {
+		if response == nil {
+			continue
+		}
+		for _, content := range response.Content {
+			if content != nil {
+				check(content.Schema)
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,173 @@
+package postedit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// package for checking and formatting the code the model writes before it is shown or saved
+
+// the most syntax errors reported for a file
+const maxErrors = 3
+
+// the lines shown on each side of a syntax error
+const excerptLines = 3
+
+var fenceRegex = regexp.MustCompile("^\\s*```[\\w+#.-]*[ \\t]*\\n")
+var closingFenceRegex = regexp.MustCompile("\\n?[ \\t]*```\\s*$")
+
+// IsMarkdown reports whether the file is markdown, where code fences are part of the contents
+func IsMarkdown(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown" || ext == ".mdx"
+}
+
+// StripFences removes the markdown code fence the model sometimes wraps code in
+func StripFences(code string) string {
+	loc := fenceRegex.FindStringIndex(code)
+	if loc == nil || !closingFenceRegex.MatchString(code[loc[1]:]) {
+		return code
+	}
+	inner := closingFenceRegex.ReplaceAllString(code[loc[1]:], "")
+	if strings.HasSuffix(code, "\n") {
+		inner += "\n"
+	}
+	return inner
+}
+
+// SyntaxError is a syntax error in an edited file
+type SyntaxError struct {
+	Path string
+	// 1-indexed, 0 if unknown
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Message)
+}
+
+// Excerpt returns the lines around the line, numbered, to show where an error is
+func Excerpt(contents string, line int) string {
+	lines := strings.Split(contents, "\n")
+	start, end := line-excerptLines, line+excerptLines
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	var b strings.Builder
+	for i := start; i <= end; i++ {
+		fmt.Fprintf(&b, "%4d | %s\n", i, lines[i-1])
+	}
+	return b.String()
+}
+
+// Checker checks that edited files parse and formats them
+type Checker struct {
+	// formatter commands by file extension, including the dot. The contents are written to the
+	// command's stdin and the formatted contents read from its stdout. {file} is replaced with
+	// the path of the file.
+	Formatters map[string]string
+}
+
+// Check returns the contents formatted. Go files are parsed and formatted with gofmt, files with
+// a formatter are run through it. Syntax errors are returned as *SyntaxError.
+func (c *Checker) Check(path, contents string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	if ext == ".go" {
+		var err error
+		contents, err = checkGo(path, contents)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if command, ok := c.Formatters[ext]; ok && command != "" {
+		return runFormatter(command, path, contents)
+	}
+	return contents, nil
+}
+
+// checkGo parses and formats Go code
+func checkGo(path, contents string) (string, error) {
+	_, err := parser.ParseFile(token.NewFileSet(), path, contents, parser.AllErrors|parser.ParseComments)
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		var errs []error
+		for i, e := range list {
+			if i == maxErrors {
+				break
+			}
+			errs = append(errs, &SyntaxError{Path: path, Line: e.Pos.Line, Message: e.Msg})
+		}
+		return "", errors.Join(errs...)
+	} else if err != nil {
+		return "", &SyntaxError{Path: path, Message: err.Error()}
+	}
+
+	formatted, err := format.Source([]byte(contents))
+	if err != nil {
+		return "", &SyntaxError{Path: path, Message: err.Error()}
+	}
+	return string(formatted), nil
+}
+
+// runFormatter formats the contents with the command. Formatters fail on code they can't parse,
+// so a failure is reported as a syntax error.
+func runFormatter(command, path, contents string) (string, error) {
+	if strings.Contains(command, "{file}") {
+		command = strings.ReplaceAll(command, "{file}", shellQuote(path))
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = strings.NewReader(contents)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = strings.TrimSpace(stdout.String())
+		}
+		return "", &SyntaxError{Path: path, Message: fmt.Sprintf("the formatter %q failed: %s", command, message)}
+	} else if err != nil {
+		return "", fmt.Errorf("could not run the formatter %q: %s", command, err)
+	}
+
+	// a formatter that writes nothing hasn't formatted anything, keep the contents
+	if stdout.Len() == 0 {
+		return contents, nil
+	}
+	return stdout.String(), nil
+}
+
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
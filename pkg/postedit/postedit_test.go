@@ -0,0 +1,58 @@
+package postedit
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStripFences(t *testing.T) {
+	cases := map[string]string{
+		"```go\nfunc main() {}\n```\n": "func main() {}\n",
+		"```\nx := 1\n```":             "x := 1",
+		"x := 1\n":                     "x := 1\n",
+		// a fence inside the code is left alone
+		"// example:\n```go\nx\n```\n": "// example:\n```go\nx\n```\n",
+	}
+	for in, want := range cases {
+		if got := StripFences(in); got != want {
+			t.Errorf("StripFences(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if !IsMarkdown("docs/README.MD") || IsMarkdown("main.go") {
+		t.Error("expected only markdown files to keep their fences")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	c := &Checker{}
+
+	formatted, err := c.Check("main.go", "package main\nfunc main(){\nx:=1\n_=x}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatted != "package main\n\nfunc main() {\n\tx := 1\n\t_ = x\n}\n" {
+		t.Errorf("expected gofmt output, got %q", formatted)
+	}
+
+	_, err = c.Check("main.go", "package main\n\nfunc main() {\n\tx := \n}\n")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 5 {
+		t.Fatalf("expected a syntax error on line 5, got %v", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	c.Formatters = map[string]string{".txt": "tr a-z A-Z", ".bad": "echo {file} is broken >&2; exit 1"}
+	formatted, err = c.Check("notes.txt", "hello\n")
+	if err != nil || formatted != "HELLO\n" {
+		t.Errorf("expected the formatter output, got %q, %v", formatted, err)
+	}
+	_, err = c.Check("x.bad", "")
+	if !errors.As(err, &syntaxErr) || !strings.Contains(err.Error(), "x.bad is broken") {
+		t.Errorf("expected the formatter failure, got %v", err)
+	}
+}
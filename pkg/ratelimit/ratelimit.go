@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter limits the number of requests and tokens sent per minute.
+// It is safe to share between goroutines.
+type Limiter struct {
+	mu                sync.Mutex
+	requestsPerMinute int
+	tokensPerMinute   int
+	window            []event
+	// used by tests to control time
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+type event struct {
+	at     time.Time
+	tokens int
+}
+
+// New creates a limiter. A limit of 0 means unlimited.
+func New(requestsPerMinute, tokensPerMinute int) *Limiter {
+	return &Limiter{
+		requestsPerMinute: requestsPerMinute,
+		tokensPerMinute:   tokensPerMinute,
+		now:               time.Now,
+		sleep:             time.Sleep,
+	}
+}
+
+// Wait blocks until a request using the given number of tokens fits within the limits,
+// then records it. A request larger than the token limit is let through once the
+// window is empty so it can't block forever.
+func (l *Limiter) Wait(tokens int) {
+	for {
+		l.mu.Lock()
+		now := l.now()
+
+		// drop everything older than a minute
+		cutoff := now.Add(-time.Minute)
+		i := 0
+		for i < len(l.window) && !l.window[i].at.After(cutoff) {
+			i++
+		}
+		l.window = l.window[i:]
+
+		used := 0
+		for _, e := range l.window {
+			used += e.tokens
+		}
+
+		requestsOk := l.requestsPerMinute <= 0 || len(l.window) < l.requestsPerMinute
+		tokensOk := l.tokensPerMinute <= 0 || used+tokens <= l.tokensPerMinute || len(l.window) == 0
+		if requestsOk && tokensOk {
+			l.window = append(l.window, event{at: now, tokens: tokens})
+			l.mu.Unlock()
+			return
+		}
+
+		// wait for the oldest request to leave the window
+		wait := l.window[0].at.Add(time.Minute).Sub(now)
+		l.mu.Unlock()
+		l.sleep(wait)
+	}
+}
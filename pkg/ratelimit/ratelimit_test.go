@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	testCases := []struct {
+		name              string
+		requestsPerMinute int
+		tokensPerMinute   int
+		requests          []int
+		expectedWait      time.Duration
+	}{
+		{
+			name:              "Under the limits",
+			requestsPerMinute: 3,
+			tokensPerMinute:   1000,
+			requests:          []int{100, 100, 100},
+			expectedWait:      0,
+		},
+		{
+			name:              "Request limit",
+			requestsPerMinute: 2,
+			requests:          []int{100, 100, 100},
+			expectedWait:      time.Minute,
+		},
+		{
+			name:            "Token limit",
+			tokensPerMinute: 250,
+			requests:        []int{100, 100, 100},
+			expectedWait:    time.Minute,
+		},
+		{
+			name:            "Single request over the token limit",
+			tokensPerMinute: 50,
+			requests:        []int{100},
+			expectedWait:    0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			var waited time.Duration
+
+			l := New(tc.requestsPerMinute, tc.tokensPerMinute)
+			l.now = func() time.Time { return now }
+			l.sleep = func(d time.Duration) {
+				waited += d
+				now = now.Add(d)
+			}
+
+			for _, tokens := range tc.requests {
+				l.Wait(tokens)
+			}
+
+			if waited != tc.expectedWait {
+				t.Errorf("Expected to wait %s, but waited %s", tc.expectedWait, waited)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package readme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// package for the sections of a README that Otto maintains. Sections are wrapped in markers:
+//
+//	<!-- otto:start usage sources=1a2b3c4d -->
+//	...
+//	<!-- otto:end usage -->
+//
+// Everything outside the markers belongs to humans and is never changed. The sources hash
+// records the code the section was written from, so stale sections can be found.
+
+var startRegex = regexp.MustCompile(`<!--\s*otto:start\s+([\w-]+)((?:\s+[\w-]+=\S+)*)\s*-->`)
+
+// Section is a section of the README maintained by Otto
+type Section struct {
+	Name string
+	// the hash of the sources the section was written from, empty if unknown
+	Sources string
+	Body    string
+	// byte offsets of the whole section in the README, including the markers
+	Start int
+	End   int
+}
+
+// Parse finds the sections in the README
+func Parse(contents string) ([]Section, error) {
+	var sections []Section
+	offset := 0
+	for {
+		loc := startRegex.FindStringSubmatchIndex(contents[offset:])
+		if loc == nil {
+			break
+		}
+		name := contents[offset+loc[2] : offset+loc[3]]
+		attrs := contents[offset+loc[4] : offset+loc[5]]
+
+		endRegex := regexp.MustCompile(`<!--\s*otto:end\s+` + regexp.QuoteMeta(name) + `\s*-->`)
+		bodyStart := offset + loc[1]
+		endLoc := endRegex.FindStringIndex(contents[bodyStart:])
+		if endLoc == nil {
+			return nil, fmt.Errorf("the section %s has no end marker", name)
+		}
+
+		section := Section{
+			Name:  name,
+			Body:  strings.Trim(contents[bodyStart:bodyStart+endLoc[0]], "\n"),
+			Start: offset + loc[0],
+			End:   bodyStart + endLoc[1],
+		}
+		for _, attr := range strings.Fields(attrs) {
+			key, value, _ := strings.Cut(attr, "=")
+			if key == "sources" {
+				section.Sources = value
+			}
+		}
+
+		sections = append(sections, section)
+		offset = section.End
+	}
+	return sections, nil
+}
+
+// Render renders the section with its markers
+func Render(name, sources, body string) string {
+	attrs := ""
+	if sources != "" {
+		attrs = " sources=" + sources
+	}
+	return fmt.Sprintf("<!-- otto:start %s%s -->\n%s\n<!-- otto:end %s -->", name, attrs, strings.Trim(body, "\n"), name)
+}
+
+// Replace replaces the bodies of the sections in the README, leaving everything else as it is.
+// Sections that aren't in the README yet are added to the end in the order given.
+func Replace(contents string, order []string, bodies map[string]string, sources map[string]string) (string, error) {
+	sections, err := Parse(contents)
+	if err != nil {
+		return "", err
+	}
+
+	found := map[string]bool{}
+	// replace from the end so the offsets stay valid
+	for i := len(sections) - 1; i >= 0; i-- {
+		section := sections[i]
+		found[section.Name] = true
+		body, ok := bodies[section.Name]
+		if !ok {
+			continue
+		}
+		contents = contents[:section.Start] + Render(section.Name, sources[section.Name], body) + contents[section.End:]
+	}
+
+	for _, name := range order {
+		body, ok := bodies[name]
+		if !ok || found[name] {
+			continue
+		}
+		contents = strings.TrimRight(contents, "\n")
+		if contents != "" {
+			contents += "\n\n"
+		}
+		contents += Render(name, sources[name], body) + "\n"
+	}
+
+	return contents, nil
+}
+
+// HashSources hashes the files a section is written from
+func HashSources(files map[string]string) string {
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(h, "%s\x00%s\x00", path, files[path])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
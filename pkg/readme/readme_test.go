@@ -0,0 +1,63 @@
+package readme
+
+import (
+	"testing"
+)
+
+func TestReplace(t *testing.T) {
+	contents := `# Project
+
+Written by a human.
+
+<!-- otto:start usage sources=abc123 -->
+## Usage
+
+Old usage.
+<!-- otto:end usage -->
+
+More human text.
+`
+
+	sections, err := Parse(contents)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sections) != 1 || sections[0].Name != "usage" || sections[0].Sources != "abc123" || sections[0].Body != "## Usage\n\nOld usage." {
+		t.Fatalf("Unexpected sections: %+v", sections)
+	}
+
+	result, err := Replace(contents, []string{"install", "usage"}, map[string]string{
+		"usage":   "## Usage\n\nNew usage.",
+		"install": "## Installation\n\nInstall it.",
+	}, map[string]string{"usage": "def456"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `# Project
+
+Written by a human.
+
+<!-- otto:start usage sources=def456 -->
+## Usage
+
+New usage.
+<!-- otto:end usage -->
+
+More human text.
+
+<!-- otto:start install -->
+## Installation
+
+Install it.
+<!-- otto:end install -->
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, result)
+	}
+
+	_, err = Parse("<!-- otto:start usage -->\nno end")
+	if err == nil {
+		t.Errorf("Expected an error for a missing end marker")
+	}
+}
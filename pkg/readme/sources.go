@@ -0,0 +1,108 @@
+package readme
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+// Kind is a section Otto knows how to write
+type Kind struct {
+	Name  string
+	Title string
+	// what the section should contain
+	Instructions string
+	// reports whether the file is one the section is written from
+	match func(filePath, contents string) bool
+}
+
+var installFiles = []string{"go.mod", "package.json", "pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Cargo.toml", "Gemfile", "composer.json", "Makefile", "Dockerfile", ".goreleaser.yml", ".goreleaser.yaml", "install.sh"}
+var contributingFiles = []string{"Makefile", "CONTRIBUTING.md", ".golangci.yml", ".golangci.yaml", ".pre-commit-config.yaml", ".editorconfig", "justfile", "Justfile", "Taskfile.yml", "tox.ini", "package.json"}
+
+// RootFiles are the files in the root of a repository that sections are written from.
+// They often aren't code, so they may need to be loaded separately.
+var RootFiles = append(append([]string{}, installFiles...), contributingFiles...)
+
+var cliRegex = regexp.MustCompile(`cobra\.Command\{|urfave/cli|\bflag\.(?:String|Bool|Int|Parse)\b|\bargparse\b|\bimport click\b|\bimport typer\b|__name__ == .__main__.|\.command\(|yargs|commander`)
+var configRegex = regexp.MustCompile(`os\.Getenv|os\.LookupEnv|process\.env\b|os\.environ|\bgetenv\(|viper\.|ENV\[`)
+
+// Kinds are the sections Otto can write, in the order they are added to a new README
+var Kinds = []Kind{
+	{
+		Name:         "install",
+		Title:        "Installation",
+		Instructions: "Explain how to install the project, including prerequisites and the commands to run. Prefer the package managers and build tools the project is set up for.",
+		match: func(filePath, _ string) bool {
+			return utils.Contains(installFiles, path.Base(filePath))
+		},
+	},
+	{
+		Name:         "usage",
+		Title:        "Usage",
+		Instructions: "Explain how to use the project with examples. For command line tools, list the commands and their most important flags as they are defined in the code.",
+		match: func(filePath, contents string) bool {
+			base := strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+			return !isTest(filePath) && (cliRegex.MatchString(contents) || base == "cli" || path.Base(filePath) == "package.json")
+		},
+	},
+	{
+		Name:         "configuration",
+		Title:        "Configuration",
+		Instructions: "Explain how to configure the project, including environment variables, config files and their options, and their defaults.",
+		match: func(filePath, contents string) bool {
+			base := path.Base(filePath)
+			return !isTest(filePath) && (configRegex.MatchString(contents) || strings.HasPrefix(base, "config.") || strings.HasSuffix(base, ".example"))
+		},
+	},
+	{
+		Name:         "contributing",
+		Title:        "Contributing",
+		Instructions: "Explain how to contribute: how to set up a development environment, build, run the tests and linters, and open a pull request.",
+		match: func(filePath, _ string) bool {
+			return utils.Contains(contributingFiles, path.Base(filePath)) || strings.HasPrefix(filePath, ".github/workflows/")
+		},
+	},
+}
+
+// GetKind returns the kind of section with the name
+func GetKind(name string) (Kind, bool) {
+	for _, kind := range Kinds {
+		if kind.Name == name {
+			return kind, true
+		}
+	}
+	return Kind{}, false
+}
+
+// Names returns the names of the kinds of sections
+func Names() []string {
+	var names []string
+	for _, kind := range Kinds {
+		names = append(names, kind.Name)
+	}
+	return names
+}
+
+// Sources returns the files the section is written from by their paths
+func (k Kind) Sources(files []prompt.GitFile) map[string]string {
+	sources := map[string]string{}
+	for _, file := range files {
+		filePath := path.Clean(file.Path)
+		// the README can't be a source for itself
+		if strings.EqualFold(path.Base(filePath), "README.md") {
+			continue
+		}
+		if k.match(filePath, file.Contents) {
+			sources[filePath] = file.Contents
+		}
+	}
+	return sources
+}
+
+func isTest(filePath string) bool {
+	base := path.Base(filePath)
+	return strings.HasSuffix(base, "_test.go") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") || strings.HasPrefix(base, "test_")
+}
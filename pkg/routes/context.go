@@ -0,0 +1,162 @@
+package routes
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/calc"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/drift"
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+// kinds of declarations that describe the shape of data
+var typeKinds = map[string]bool{"type": true, "struct": true, "interface": true, "class": true, "enum": true, "trait": true}
+
+var identRegex = regexp.MustCompile(`[A-Za-z_]\w*`)
+
+// how many levels of references are followed from the handler, so the types of the fields of a
+// request type are included as well
+const maxReferenceDepth = 2
+
+// Index finds the declarations of a repository by name
+type Index struct {
+	byName map[string][]drift.Symbol
+}
+
+// NewIndex indexes the declarations in the files
+func NewIndex(files []prompt.GitFile) *Index {
+	index := &Index{byName: map[string][]drift.Symbol{}}
+	for _, file := range files {
+		syms, err := drift.Symbols(path.Clean(file.Path), file.Contents)
+		if err != nil {
+			continue
+		}
+		for _, sym := range syms {
+			index.byName[sym.Symbol.Name] = append(index.byName[sym.Symbol.Name], sym)
+		}
+	}
+	return index
+}
+
+// find returns the declaration with the name that the code in the file most likely refers to:
+// the one in the same directory, or the only one
+func (idx *Index) find(name, from string, kinds func(string) bool) (drift.Symbol, bool) {
+	var matches, near []drift.Symbol
+	for _, sym := range idx.byName[name] {
+		if !kinds(sym.Symbol.Kind) {
+			continue
+		}
+		matches = append(matches, sym)
+		if path.Dir(sym.Path) == path.Dir(from) {
+			near = append(near, sym)
+		}
+	}
+	if len(near) == 1 {
+		return near[0], true
+	}
+	if len(matches) == 1 {
+		return matches[0], true
+	}
+	return drift.Symbol{}, false
+}
+
+// References returns the declarations the code declared on the line of the file refers to,
+// types first and then functions, following the references of the types and functions found
+func (idx *Index) References(code, from string, line int) []drift.Symbol {
+	added := map[string]bool{fmt.Sprintf("%s:%d", from, line): true}
+	add := func(sym drift.Symbol) bool {
+		key := fmt.Sprintf("%s:%d", sym.Path, sym.Symbol.Line)
+		if added[key] {
+			return false
+		}
+		added[key] = true
+		return true
+	}
+
+	isType := func(kind string) bool { return typeKinds[kind] }
+	isFunc := func(kind string) bool { return kind == "func" || kind == "method" }
+
+	var types, funcs []drift.Symbol
+	var visit func(code, from string, depth int)
+	visit = func(code, from string, depth int) {
+		for _, name := range identRegex.FindAllString(code, -1) {
+			if sym, ok := idx.find(name, from, isType); ok {
+				if add(sym) {
+					types = append(types, sym)
+					if depth < maxReferenceDepth {
+						visit(sym.Code, sym.Path, depth+1)
+					}
+				}
+				continue
+			}
+			// only the functions the code itself calls, and the types they use
+			if depth == 1 {
+				if sym, ok := idx.find(name, from, isFunc); ok && add(sym) {
+					funcs = append(funcs, sym)
+					visit(sym.Code, sym.Path, depth+1)
+				}
+			}
+		}
+	}
+	visit(code, from, 1)
+
+	return append(types, funcs...)
+}
+
+// Context is the code endpoints are documented from
+type Context struct {
+	Index *Index
+	// files that are always included, after the handler
+	Extra []prompt.GitFile
+	// files used when the handler of a route wasn't found
+	Fallback []prompt.GitFile
+	// the most tokens of code to include
+	Budget int
+}
+
+// section formats code for the prompt
+func section(title, code string) string {
+	return "# " + title + "\n\n" + code + "\n\n---\n\n"
+}
+
+// For returns the code to document the route from: the source of its handler, the extra files
+// and the declarations the handler refers to, as many as fit in the budget. Routes whose handler
+// wasn't found get the extra files and then the fallback files. Also reports whether anything
+// was left out to stay within the budget.
+func (c *Context) For(route Route) ([]string, bool) {
+	var out []string
+	tokens := 0
+	truncated := false
+	add := func(text string) {
+		cost := calc.EstimateTokens(text)
+		// the handler is always included
+		if len(out) > 0 && tokens+cost > c.Budget {
+			truncated = true
+			return
+		}
+		out = append(out, text)
+		tokens += cost
+	}
+
+	if route.Source != "" {
+		add(section(fmt.Sprintf("%s:%d (handler of %s, registered at %s)", route.HandlerFile, route.HandlerLine, route.Endpoint(), route.Location()), route.Source))
+	}
+	for _, file := range c.Extra {
+		add(section(file.Path, file.Contents))
+	}
+
+	if route.Source == "" {
+		for _, file := range c.Fallback {
+			add(section(file.Path, file.Contents))
+		}
+		return out, truncated
+	}
+
+	if c.Index != nil {
+		for _, sym := range c.Index.References(route.Source, route.HandlerFile, route.HandlerLine) {
+			add(section(fmt.Sprintf("%s:%d", sym.Path, sym.Symbol.Line), sym.Code))
+		}
+	}
+	return out, truncated
+}
@@ -0,0 +1,305 @@
+package routes
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+// the frameworks by the path of their package, without the major version
+var goFrameworks = map[string]string{
+	"net/http":                 "net/http",
+	"github.com/gin-gonic/gin": "gin",
+	"github.com/labstack/echo": "echo",
+	"github.com/go-chi/chi":    "chi",
+	"github.com/gofiber/fiber": "fiber",
+}
+
+var upperMethods = map[string]string{
+	"GET": "GET", "POST": "POST", "PUT": "PUT", "PATCH": "PATCH", "DELETE": "DELETE",
+	"HEAD": "HEAD", "OPTIONS": "OPTIONS", "TRACE": "TRACE", "CONNECT": "CONNECT", "Any": AnyMethod,
+}
+
+var titleMethods = map[string]string{
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE",
+	"Head": "HEAD", "Options": "OPTIONS", "Trace": "TRACE", "Connect": "CONNECT", "All": AnyMethod,
+}
+
+// goFramework returns the framework the package is, if any
+func goFramework(importPath string) (string, bool) {
+	parts := strings.Split(importPath, "/")
+	// drop a major version suffix such as /v5
+	if last := parts[len(parts)-1]; len(parts) > 1 && len(last) > 1 && last[0] == 'v' && strings.Trim(last[1:], "0123456789") == "" {
+		importPath = strings.Join(parts[:len(parts)-1], "/")
+	}
+	framework, ok := goFrameworks[importPath]
+	return framework, ok
+}
+
+type goExtractor struct {
+	fset       *token.FileSet
+	path       string
+	contents   string
+	frameworks map[string]bool
+	routes     []Route
+}
+
+func extractGo(files []prompt.GitFile) []Route {
+	var routes []Route
+	for _, file := range files {
+		filePath := path.Clean(file.Path)
+		if path.Ext(filePath) != ".go" || strings.HasSuffix(filePath, "_test.go") {
+			continue
+		}
+
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, filePath, file.Contents, 0)
+		if err != nil {
+			continue
+		}
+
+		e := &goExtractor{fset: fset, path: filePath, contents: file.Contents, frameworks: map[string]bool{}}
+		for _, spec := range f.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if framework, ok := goFramework(importPath); ok {
+				e.frameworks[framework] = true
+			}
+		}
+		if len(e.frameworks) == 0 {
+			continue
+		}
+
+		e.walk(f, map[string]string{})
+		routes = append(routes, e.routes...)
+	}
+	return routes
+}
+
+// walk finds the routes registered in the node. prefixes are the path prefixes of the
+// router groups in scope by their variable names.
+func (e *goExtractor) walk(node ast.Node, prefixes map[string]string) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, rhs := range n.Rhs {
+				if i >= len(n.Lhs) {
+					break
+				}
+				if ident, ok := n.Lhs[i].(*ast.Ident); ok {
+					if prefix, ok := e.groupPrefix(rhs, prefixes); ok {
+						prefixes[ident.Name] = prefix
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, value := range n.Values {
+				if i < len(n.Names) {
+					if prefix, ok := e.groupPrefix(value, prefixes); ok {
+						prefixes[n.Names[i].Name] = prefix
+					}
+				}
+			}
+		case *ast.CallExpr:
+			return !e.call(n, prefixes)
+		}
+		return true
+	})
+}
+
+// groupPrefix returns the prefix of the router group the expression creates
+func (e *goExtractor) groupPrefix(expr ast.Expr, prefixes map[string]string) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Group" || len(call.Args) == 0 {
+		return "", false
+	}
+	p, ok := stringLit(call.Args[0])
+	if !ok {
+		return "", false
+	}
+	return joinPath(e.prefix(sel.X, prefixes), p), true
+}
+
+// prefix returns the path prefix of the router the expression is
+func (e *goExtractor) prefix(expr ast.Expr, prefixes map[string]string) string {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return prefixes[x.Name]
+	case *ast.ParenExpr:
+		return e.prefix(x.X, prefixes)
+	case *ast.CallExpr:
+		if prefix, ok := e.groupPrefix(x, prefixes); ok {
+			return prefix
+		}
+		// chained calls such as chi's r.With(middleware) keep the prefix
+		if sel, ok := x.Fun.(*ast.SelectorExpr); ok {
+			return e.prefix(sel.X, prefixes)
+		}
+	}
+	return ""
+}
+
+// call records the route the call registers. Returns true if the call was handled
+// completely, so its arguments shouldn't be walked again.
+func (e *goExtractor) call(call *ast.CallExpr, prefixes map[string]string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	name := sel.Sel.Name
+	args := call.Args
+
+	// chi's r.Route("/users", func(r chi.Router) {...}) and r.Group(func(r chi.Router) {...})
+	// and fiber's app.Route("/users", func(router fiber.Router) {...})
+	if (name == "Route" || name == "Group") && len(args) > 0 {
+		prefix := e.prefix(sel.X, prefixes)
+		fn, ok := args[len(args)-1].(*ast.FuncLit)
+		if name == "Route" && len(args) > 2 {
+			// fiber takes a name after the function
+			fn, ok = args[1].(*ast.FuncLit)
+		}
+		if ok {
+			if p, isString := stringLit(args[0]); isString {
+				prefix = joinPath(prefix, p)
+			} else if name == "Route" {
+				return false
+			}
+			scoped := map[string]string{}
+			for k, v := range prefixes {
+				scoped[k] = v
+			}
+			if params := fn.Type.Params.List; len(params) > 0 && len(params[0].Names) > 0 {
+				scoped[params[0].Names[0].Name] = prefix
+			}
+			e.walk(fn.Body, scoped)
+			return true
+		}
+		return false
+	}
+
+	method, framework, pathArg, handlerArg, ok := e.registration(name, len(args))
+	if !ok {
+		return false
+	}
+	p, ok := stringLit(args[pathArg])
+	if !ok {
+		return false
+	}
+	if pathArg > 0 {
+		m, isString := stringLit(args[0])
+		if !isString {
+			return false
+		}
+		method = strings.ToUpper(m)
+	}
+	// net/http patterns can start with a method and a host. Routers other than chi's may be
+	// from net/http even if chi is imported as well.
+	if m, rest, found := strings.Cut(p, " "); found && method == AnyMethod {
+		method, p, framework = strings.ToUpper(m), strings.TrimSpace(rest), "net/http"
+	}
+	if i := strings.Index(p, "/"); framework == "net/http" && i > 0 {
+		p = p[i:]
+	}
+
+	route := Route{
+		Method:    method,
+		Path:      joinPath(e.prefix(sel.X, prefixes), p),
+		Framework: framework,
+		File:      e.path,
+		Line:      e.fset.Position(call.Pos()).Line,
+	}
+
+	handler := args[handlerArg]
+	// http.HandlerFunc(handler) conversions
+	if conv, ok := handler.(*ast.CallExpr); ok && len(conv.Args) == 1 {
+		if s, ok := conv.Fun.(*ast.SelectorExpr); ok && s.Sel.Name == "HandlerFunc" {
+			handler = conv.Args[0]
+		}
+	}
+	if fn, ok := handler.(*ast.FuncLit); ok {
+		route.Source = e.contents[e.fset.Position(fn.Pos()).Offset:e.fset.Position(fn.End()).Offset]
+		route.HandlerFile = e.path
+		route.HandlerLine = e.fset.Position(fn.Pos()).Line
+	} else {
+		route.Handler = types.ExprString(handler)
+	}
+
+	e.routes = append(e.routes, route)
+	return false
+}
+
+// registration returns the method, framework and the indexes of the path and handler arguments
+// if the method named registers a route in one of the frameworks the file imports. If the
+// path isn't the first argument, the first is the method.
+func (e *goExtractor) registration(name string, nargs int) (string, string, int, int, bool) {
+	if nargs < 2 {
+		return "", "", 0, 0, false
+	}
+	last := nargs - 1
+
+	if method, ok := upperMethods[name]; ok {
+		switch {
+		case e.frameworks["echo"]:
+			// echo takes middleware after the handler
+			return method, "echo", 0, 1, true
+		case e.frameworks["gin"]:
+			return method, "gin", 0, last, true
+		}
+	}
+	if method, ok := titleMethods[name]; ok {
+		switch {
+		case e.frameworks["chi"] && name != "All":
+			return method, "chi", 0, last, true
+		case e.frameworks["fiber"]:
+			return method, "fiber", 0, last, true
+		}
+	}
+
+	switch name {
+	case "Handle", "HandleFunc":
+		switch {
+		case e.frameworks["gin"] && name == "Handle" && nargs >= 3:
+			return "", "gin", 1, last, true
+		case e.frameworks["chi"]:
+			return AnyMethod, "chi", 0, 1, true
+		case e.frameworks["net/http"]:
+			return AnyMethod, "net/http", 0, 1, true
+		}
+	case "Method", "MethodFunc":
+		if e.frameworks["chi"] && nargs >= 3 {
+			return "", "chi", 1, 2, true
+		}
+	case "Add":
+		if e.frameworks["echo"] && nargs >= 3 {
+			return "", "echo", 1, 2, true
+		}
+		if e.frameworks["fiber"] && nargs >= 3 {
+			return "", "fiber", 1, last, true
+		}
+	}
+	return "", "", 0, 0, false
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return s, true
+}
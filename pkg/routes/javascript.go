@@ -0,0 +1,179 @@
+package routes
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+var jsExtensions = []string{".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs"}
+
+var expressRegex = regexp.MustCompile(`\bexpress\b|\bRouter\(\s*\)`)
+var jsRouteRegex = regexp.MustCompile("\\b([A-Za-z_$][\\w$]*)\\.(get|post|put|patch|delete|head|options|all)\\(\\s*['\"`]([^'\"`]*)['\"`]\\s*,")
+var jsUseRegex = regexp.MustCompile("\\b([A-Za-z_$][\\w$]*)\\.use\\(\\s*['\"`]([^'\"`]*)['\"`]\\s*,\\s*(?:require\\(\\s*['\"]([^'\"]+)['\"]\\s*\\)|([A-Za-z_$][\\w$]*))\\s*\\)")
+var jsRequireRegex = regexp.MustCompile(`(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*=\s*require\(\s*['"]([^'"]+)['"]\s*\)`)
+var jsImportRegex = regexp.MustCompile(`import\s+([A-Za-z_$][\w$]*)\s+from\s+['"]([^'"]+)['"]`)
+var jsIdentRegex = regexp.MustCompile(`^[A-Za-z_$][\w$.]*$`)
+
+// a router mounted on a path of another
+type mount struct {
+	parent string
+	prefix string
+}
+
+// jsFile is the routes registered in a file and how its routers are mounted
+type jsFile struct {
+	routes []Route
+	// the router variable of each route
+	routers []string
+	// routers of the file mounted on other routers of the file
+	mounts map[string]mount
+	// files mounted on routers of the file by their paths
+	fileMounts map[string]mount
+}
+
+// prefix returns the prefix of the router in the file, following its mounts
+func (f *jsFile) prefix(router string, seen map[string]bool) string {
+	m, ok := f.mounts[router]
+	if !ok || seen[router] {
+		return ""
+	}
+	seen[router] = true
+	return joinPath(f.prefix(m.parent, seen), m.prefix)
+}
+
+func extractJS(files []prompt.GitFile) []Route {
+	paths := map[string]bool{}
+	for _, file := range files {
+		paths[path.Clean(file.Path)] = true
+	}
+
+	parsed := map[string]*jsFile{}
+	var order []string
+	for _, file := range files {
+		filePath := path.Clean(file.Path)
+		if !utils.Contains(jsExtensions, path.Ext(filePath)) || !expressRegex.MatchString(file.Contents) {
+			continue
+		}
+		parsed[filePath] = parseJS(filePath, file.Contents, paths)
+		order = append(order, filePath)
+	}
+
+	// the prefix of each file is where it's mounted by another file
+	mountedAt := map[string]mount{}
+	parents := map[string]string{}
+	for _, filePath := range order {
+		for target, m := range parsed[filePath].fileMounts {
+			mountedAt[target] = m
+			parents[target] = filePath
+		}
+	}
+	var filePrefix func(filePath string, seen map[string]bool) string
+	filePrefix = func(filePath string, seen map[string]bool) string {
+		parent, ok := parents[filePath]
+		if !ok || seen[filePath] {
+			return ""
+		}
+		seen[filePath] = true
+		m := mountedAt[filePath]
+		return joinPath(joinPath(filePrefix(parent, seen), parsed[parent].prefix(m.parent, map[string]bool{})), m.prefix)
+	}
+
+	var routes []Route
+	for _, filePath := range order {
+		f := parsed[filePath]
+		prefix := filePrefix(filePath, map[string]bool{})
+		for i, route := range f.routes {
+			route.Path = joinPath(joinPath(prefix, f.prefix(f.routers[i], map[string]bool{})), route.Path)
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+func parseJS(filePath, contents string, paths map[string]bool) *jsFile {
+	f := &jsFile{mounts: map[string]mount{}, fileMounts: map[string]mount{}}
+
+	// relative imports by the variable they're assigned to
+	imports := map[string]string{}
+	for _, re := range []*regexp.Regexp{jsRequireRegex, jsImportRegex} {
+		for _, match := range re.FindAllStringSubmatch(contents, -1) {
+			if target, ok := resolveJS(filePath, match[2], paths); ok {
+				imports[match[1]] = target
+			}
+		}
+	}
+
+	for _, match := range jsUseRegex.FindAllStringSubmatch(contents, -1) {
+		m := mount{parent: match[1], prefix: match[2]}
+		if match[3] != "" {
+			if target, ok := resolveJS(filePath, match[3], paths); ok {
+				f.fileMounts[target] = m
+			}
+		} else if target, ok := imports[match[4]]; ok {
+			f.fileMounts[target] = m
+		} else {
+			f.mounts[match[4]] = m
+		}
+	}
+
+	for _, loc := range jsRouteRegex.FindAllStringSubmatchIndex(contents, -1) {
+		p := contents[loc[6]:loc[7]]
+		if strings.Contains(p, "${") {
+			continue
+		}
+		route := Route{
+			Method:    strings.ToUpper(contents[loc[4]:loc[5]]),
+			Path:      p,
+			Framework: "express",
+			File:      filePath,
+			Line:      line(contents, loc[0]),
+		}
+		if route.Method == "ALL" {
+			route.Method = AnyMethod
+		}
+
+		// the handler is the last argument, after any middleware
+		open := loc[5]
+		end := closing(contents, open)
+		if end < 0 {
+			continue
+		}
+		args := splitArgs(contents[open+1 : end-1])
+		handler := args[len(args)-1]
+		if jsIdentRegex.MatchString(handler) {
+			route.Handler = handler
+		} else {
+			route.Source = contents[loc[0]:end]
+			route.HandlerFile = filePath
+			route.HandlerLine = route.Line
+		}
+
+		f.routes = append(f.routes, route)
+		f.routers = append(f.routers, contents[loc[2]:loc[3]])
+	}
+	return f
+}
+
+// resolveJS finds the file a relative import refers to
+func resolveJS(from, spec string, paths map[string]bool) (string, bool) {
+	if !strings.HasPrefix(spec, ".") {
+		return "", false
+	}
+	target := path.Join(path.Dir(from), spec)
+	if paths[target] {
+		return target, true
+	}
+	for _, ext := range jsExtensions {
+		if paths[target+ext] {
+			return target + ext, true
+		}
+		if paths[target+"/index"+ext] {
+			return target + "/index" + ext, true
+		}
+	}
+	return "", false
+}
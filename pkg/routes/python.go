@@ -0,0 +1,267 @@
+package routes
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+var fastapiRegex = regexp.MustCompile(`\bfastapi\b`)
+var flaskRegex = regexp.MustCompile(`\bflask\b`)
+var pyRouteRegex = regexp.MustCompile(`(?m)^[ \t]*@([A-Za-z_][\w.]*)\.(get|post|put|patch|delete|head|options|trace|route|api_route)\(`)
+var pyRouterRegex = regexp.MustCompile(`(?m)^[ \t]*(\w+)\s*(?::[^=\n]+)?=\s*(?:[\w.]+\.)?(?:APIRouter|Blueprint|FastAPI|Flask)\(`)
+var pyMountRegex = regexp.MustCompile(`\b(\w+)\.(?:include_router|register_blueprint)\(`)
+var pyFromImportRegex = regexp.MustCompile(`(?m)^[ \t]*from\s+(\.*[\w.]*)\s+import\s+(?:\(([\w\s,]+)\)|([\w \t,]+))`)
+var pyDefRegex = regexp.MustCompile(`(?m)^[ \t]*(?:async\s+)?def\s+(\w+)`)
+var pyKeywordRegex = regexp.MustCompile(`^\s*\w+\s*$`)
+var pyMethodRegex = regexp.MustCompile(`['"](\w+)['"]`)
+var pyStringRegex = regexp.MustCompile(`^[rfbu]?(?:'([^']*)'|"([^"]*)")$`)
+
+// pyFile is the routes registered in a file and how its routers are mounted
+type pyFile struct {
+	framework string
+	routes    []Route
+	// the router variable of each route
+	routers []string
+	// the prefixes routers of the file were created with, and where they're mounted on other routers of the file
+	prefixes map[string]string
+	mounts   map[string]mount
+	// files mounted on routers of the file by their paths
+	fileMounts map[string]mount
+}
+
+// prefix returns the prefix of the router in the file, following its mounts
+func (f *pyFile) prefix(router string, seen map[string]bool) string {
+	prefix := f.prefixes[router]
+	m, ok := f.mounts[router]
+	if !ok || seen[router] {
+		return prefix
+	}
+	seen[router] = true
+	return joinPath(joinPath(f.prefix(m.parent, seen), m.prefix), prefix)
+}
+
+func extractPython(files []prompt.GitFile) []Route {
+	var pyPaths []string
+	for _, file := range files {
+		if path.Ext(file.Path) == ".py" {
+			pyPaths = append(pyPaths, path.Clean(file.Path))
+		}
+	}
+
+	parsed := map[string]*pyFile{}
+	var order []string
+	for _, file := range files {
+		filePath := path.Clean(file.Path)
+		if path.Ext(filePath) != ".py" {
+			continue
+		}
+		framework := ""
+		if fastapiRegex.MatchString(file.Contents) {
+			framework = "fastapi"
+		} else if flaskRegex.MatchString(file.Contents) {
+			framework = "flask"
+		} else {
+			continue
+		}
+		parsed[filePath] = parsePython(filePath, file.Contents, framework, pyPaths)
+		order = append(order, filePath)
+	}
+
+	mountedAt := map[string]mount{}
+	parents := map[string]string{}
+	for _, filePath := range order {
+		for target, m := range parsed[filePath].fileMounts {
+			mountedAt[target] = m
+			parents[target] = filePath
+		}
+	}
+	var filePrefix func(filePath string, seen map[string]bool) string
+	filePrefix = func(filePath string, seen map[string]bool) string {
+		parent, ok := parents[filePath]
+		if !ok || seen[filePath] || parsed[parent] == nil {
+			return ""
+		}
+		seen[filePath] = true
+		m := mountedAt[filePath]
+		return joinPath(joinPath(filePrefix(parent, seen), parsed[parent].prefix(m.parent, map[string]bool{})), m.prefix)
+	}
+
+	var routes []Route
+	for _, filePath := range order {
+		f := parsed[filePath]
+		prefix := filePrefix(filePath, map[string]bool{})
+		for i, route := range f.routes {
+			route.Path = joinPath(joinPath(prefix, f.prefix(f.routers[i], map[string]bool{})), route.Path)
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// callArgs returns the positional arguments and the keyword arguments of the call whose
+// parenthesis opens at the offset
+func callArgs(contents string, open int) ([]string, map[string]string, int) {
+	end := closing(contents, open)
+	if end < 0 {
+		return nil, nil, -1
+	}
+	var positional []string
+	keywords := map[string]string{}
+	for _, arg := range splitArgs(contents[open+1 : end-1]) {
+		key, value, found := strings.Cut(arg, "=")
+		if found && pyKeywordRegex.MatchString(key) && !strings.HasPrefix(value, "=") {
+			keywords[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	return positional, keywords, end
+}
+
+func pyString(expr string) (string, bool) {
+	match := pyStringRegex.FindStringSubmatch(strings.TrimSpace(expr))
+	if match == nil {
+		return "", false
+	}
+	return match[1] + match[2], true
+}
+
+func parsePython(filePath, contents, framework string, pyPaths []string) *pyFile {
+	f := &pyFile{framework: framework, prefixes: map[string]string{}, mounts: map[string]mount{}, fileMounts: map[string]mount{}}
+
+	for _, loc := range pyRouterRegex.FindAllStringSubmatchIndex(contents, -1) {
+		_, keywords, _ := callArgs(contents, loc[1]-1)
+		router := contents[loc[2]:loc[3]]
+		f.prefixes[router] = ""
+		for _, key := range []string{"prefix", "url_prefix"} {
+			if prefix, ok := pyString(keywords[key]); ok {
+				f.prefixes[router] = prefix
+			}
+		}
+	}
+
+	// the module each imported name comes from
+	modules := map[string]string{}
+	for _, match := range pyFromImportRegex.FindAllStringSubmatch(contents, -1) {
+		for _, name := range strings.Split(match[2]+match[3], ",") {
+			fields := strings.Fields(name)
+			if len(fields) == 0 {
+				continue
+			}
+			alias := fields[len(fields)-1]
+			modules[alias] = match[1] + "." + fields[0]
+		}
+	}
+
+	for _, loc := range pyMountRegex.FindAllStringSubmatchIndex(contents, -1) {
+		positional, keywords, _ := callArgs(contents, loc[1]-1)
+		if len(positional) == 0 {
+			continue
+		}
+		m := mount{parent: contents[loc[2]:loc[3]]}
+		for _, key := range []string{"prefix", "url_prefix"} {
+			if prefix, ok := pyString(keywords[key]); ok {
+				m.prefix = prefix
+			}
+		}
+
+		target := positional[0]
+		if _, local := f.prefixes[target]; local {
+			f.mounts[target] = m
+			continue
+		}
+		if targetPath, ok := resolvePython(filePath, target, modules, pyPaths); ok {
+			f.fileMounts[targetPath] = m
+		}
+	}
+
+	for _, loc := range pyRouteRegex.FindAllStringSubmatchIndex(contents, -1) {
+		positional, keywords, end := callArgs(contents, loc[1]-1)
+		if end < 0 {
+			continue
+		}
+
+		p, ok := "", false
+		if len(positional) > 0 {
+			p, ok = pyString(positional[0])
+		}
+		for _, key := range []string{"path", "rule"} {
+			if value, found := keywords[key]; found {
+				p, ok = pyString(value)
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		verb := contents[loc[4]:loc[5]]
+		methods := []string{strings.ToUpper(verb)}
+		if verb == "route" || verb == "api_route" {
+			methods = []string{"GET"}
+			if list, found := keywords["methods"]; found {
+				methods = nil
+				for _, match := range pyMethodRegex.FindAllStringSubmatch(list, -1) {
+					methods = append(methods, strings.ToUpper(match[1]))
+				}
+			}
+		}
+
+		handler, handlerLine := "", 0
+		if def := pyDefRegex.FindStringSubmatchIndex(contents[end:]); def != nil {
+			handler = contents[end+def[2] : end+def[3]]
+			handlerLine = line(contents, end+def[2])
+		}
+
+		for _, method := range methods {
+			f.routes = append(f.routes, Route{
+				Method:      method,
+				Path:        p,
+				Framework:   framework,
+				File:        filePath,
+				Line:        line(contents, loc[0]+strings.Index(contents[loc[0]:], "@")),
+				Handler:     handler,
+				HandlerFile: filePath,
+				HandlerLine: handlerLine,
+			})
+			f.routers = append(f.routers, contents[loc[2]:loc[3]])
+		}
+	}
+	return f
+}
+
+// resolvePython finds the file of the module a mounted router comes from. The router is
+// either an attribute of an imported module, like users.router, or imported from it.
+func resolvePython(from, target string, modules map[string]string, pyPaths []string) (string, bool) {
+	var module string
+	if head, _, found := strings.Cut(target, "."); found {
+		module = head
+	} else if imported, ok := modules[target]; ok {
+		parts := strings.Split(strings.Trim(imported, "."), ".")
+		if len(parts) < 2 {
+			return "", false
+		}
+		module = parts[len(parts)-2]
+	} else {
+		return "", false
+	}
+
+	var candidates []string
+	for _, p := range pyPaths {
+		if path.Base(p) == module+".py" || p == module+"/__init__.py" || strings.HasSuffix(p, "/"+module+"/__init__.py") {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	// prefer the module next to the file importing it
+	for _, candidate := range candidates {
+		if path.Dir(candidate) == path.Dir(from) || path.Dir(path.Dir(candidate)) == path.Dir(from) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
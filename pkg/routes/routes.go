@@ -0,0 +1,229 @@
+package routes
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/drift"
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+// package for finding the routes of web frameworks in source code without the model. Go is
+// parsed with go/ast, JavaScript, TypeScript and Python with regular expressions.
+
+// AnyMethod is the method of routes that match every method
+const AnyMethod = "ANY"
+
+// the order methods are sorted in
+var methodOrder = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "TRACE", "CONNECT", AnyMethod}
+
+// Route is an endpoint registered with a router
+type Route struct {
+	// uppercase HTTP method, or AnyMethod
+	Method string
+	// the path as written for the framework, including the prefixes of its groups
+	Path      string
+	Framework string
+	// where the route is registered
+	File string
+	Line int
+	// the handler as written in the registration, empty for inline handlers
+	Handler string
+	// the source of the handler and where it is, empty if it wasn't found
+	Source      string
+	HandlerFile string
+	HandlerLine int
+}
+
+// Endpoint is the method and path of the route, for example GET /users/:id
+func (r Route) Endpoint() string {
+	return r.Method + " " + r.Path
+}
+
+// Location is where the route is registered, in the form path:line
+func (r Route) Location() string {
+	return fmt.Sprintf("%s:%d", r.File, r.Line)
+}
+
+// Extract finds the routes registered in the files
+func Extract(files []prompt.GitFile) []Route {
+	var routes []Route
+	routes = append(routes, extractGo(files)...)
+	routes = append(routes, extractJS(files)...)
+	routes = append(routes, extractPython(files)...)
+
+	// the same route can be found more than once, for example through two mounts
+	seen := map[string]bool{}
+	var unique []Route
+	for _, route := range routes {
+		if seen[route.Endpoint()] {
+			continue
+		}
+		seen[route.Endpoint()] = true
+		unique = append(unique, route)
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		if unique[i].Path != unique[j].Path {
+			return unique[i].Path < unique[j].Path
+		}
+		return methodIndex(unique[i].Method) < methodIndex(unique[j].Method)
+	})
+	return unique
+}
+
+func methodIndex(method string) int {
+	for i, m := range methodOrder {
+		if m == method {
+			return i
+		}
+	}
+	return len(methodOrder)
+}
+
+// ResolveHandlers finds the source of the handlers of the routes in the index. Handlers are
+// found by name, preferring declarations in the file the route is registered in, then in its
+// directory, then anywhere as long as the name is unique.
+func ResolveHandlers(routes []Route, index *Index) {
+	for i := range routes {
+		route := &routes[i]
+		if route.Source != "" || route.Handler == "" {
+			continue
+		}
+		sym, ok := findHandler(index, route)
+		if !ok {
+			continue
+		}
+		route.Source = sym.Code
+		route.HandlerFile = sym.Path
+		route.HandlerLine = sym.Symbol.Line
+	}
+}
+
+func findHandler(index *Index, route *Route) (drift.Symbol, bool) {
+	name := route.Handler
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	var candidates []drift.Symbol
+	for _, sym := range index.byName[name] {
+		if sym.Symbol.Kind == "func" || sym.Symbol.Kind == "method" {
+			candidates = append(candidates, sym)
+		}
+	}
+
+	// the extractor may already know where the handler is
+	if route.HandlerLine > 0 {
+		for _, sym := range candidates {
+			if sym.Path == route.HandlerFile && sym.Symbol.Line == route.HandlerLine {
+				return sym, true
+			}
+		}
+	}
+
+	for _, same := range []func(drift.Symbol) bool{
+		func(sym drift.Symbol) bool { return sym.Path == route.File },
+		func(sym drift.Symbol) bool { return path.Dir(sym.Path) == path.Dir(route.File) },
+		func(sym drift.Symbol) bool { return path.Ext(sym.Path) == path.Ext(route.File) },
+	} {
+		var matches []drift.Symbol
+		for _, sym := range candidates {
+			if same(sym) {
+				matches = append(matches, sym)
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0], true
+		}
+		if len(matches) > 1 {
+			// ambiguous, a wrong handler is worse than none
+			return drift.Symbol{}, false
+		}
+	}
+	return drift.Symbol{}, false
+}
+
+// joinPath adds the path to the prefix of its group
+func joinPath(prefix, p string) string {
+	joined := strings.TrimSuffix(prefix, "/")
+	if p != "" && p != "/" {
+		joined += "/" + strings.TrimPrefix(p, "/")
+	}
+	if !strings.HasPrefix(joined, "/") {
+		joined = "/" + joined
+	}
+	return joined
+}
+
+// line returns the 1-indexed line of the byte offset
+func line(contents string, offset int) int {
+	return strings.Count(contents[:offset], "\n") + 1
+}
+
+// closing returns the offset just after the parenthesis that closes the one at open, skipping
+// strings. Returns -1 if it isn't closed.
+func closing(contents string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(contents); i++ {
+		c := contents[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// splitArgs splits the arguments of a call at the top level commas
+func splitArgs(args string) []string {
+	var out []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(args); i++ {
+		c := args[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, strings.TrimSpace(args[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(args[start:]); last != "" {
+		out = append(out, last)
+	}
+	return out
+}
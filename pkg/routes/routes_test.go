@@ -0,0 +1,222 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chand1012/git2gpt/prompt"
+)
+
+func endpoints(routes []Route) string {
+	var out []string
+	for _, route := range routes {
+		out = append(out, route.Endpoint())
+	}
+	return strings.Join(out, "\n")
+}
+
+func TestExtractGo(t *testing.T) {
+	files := []prompt.GitFile{
+		{Path: "server/gin.go", Contents: `package server
+
+import "github.com/gin-gonic/gin"
+
+func Routes(r *gin.Engine, h *Handlers) {
+	v1 := r.Group("/api/v1")
+	users := v1.Group("/users")
+	users.GET("/:id", auth(), h.GetUser)
+	users.POST("", h.CreateUser)
+	r.Handle("PURGE", "/cache", func(c *gin.Context) {})
+}
+`},
+		{Path: "server/handlers.go", Contents: `package server
+
+func (h *Handlers) GetUser(c *gin.Context) {
+	c.JSON(200, nil)
+}
+`},
+		{Path: "chi/main.go", Contents: `package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func main() {
+	r := chi.NewRouter()
+	r.Route("/articles", func(r chi.Router) {
+		r.Get("/", listArticles)
+		r.With(paginate).Get("/{articleID}", getArticle)
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /items/{id}", deleteItem)
+	mux.Handle("/health", http.HandlerFunc(health))
+}
+`},
+	}
+
+	routes := Extract(files)
+	expected := "POST /api/v1/users\nGET /api/v1/users/:id\nGET /articles\nGET /articles/{articleID}\nPURGE /cache\nANY /health\nDELETE /items/{id}"
+	if endpoints(routes) != expected {
+		t.Fatalf("Expected the routes:\n%s\nbut got:\n%s", expected, endpoints(routes))
+	}
+
+	if routes[1].Framework != "gin" || routes[1].Handler != "h.GetUser" || routes[1].Location() != "server/gin.go:8" {
+		t.Errorf("Unexpected route: %+v", routes[1])
+	}
+	if !strings.Contains(routes[4].Source, "func(c *gin.Context)") {
+		t.Errorf("Expected the inline handler to be the source, but got %q", routes[4].Source)
+	}
+	if routes[5].Handler != "health" {
+		t.Errorf("Expected the HandlerFunc conversion to be unwrapped, but got %q", routes[5].Handler)
+	}
+
+	ResolveHandlers(routes, NewIndex(files))
+	if !strings.Contains(routes[1].Source, "c.JSON(200, nil)") || routes[1].HandlerFile != "server/handlers.go" {
+		t.Errorf("Expected the handler to be resolved, but got %+v", routes[1])
+	}
+}
+
+func TestExtractJS(t *testing.T) {
+	files := []prompt.GitFile{
+		{Path: "app.js", Contents: `const express = require('express');
+const users = require('./routes/users');
+const app = express();
+app.set('view engine', 'pug');
+app.get('env');
+app.use('/api/users', users);
+app.get('/', (req, res) => {
+  res.send('ok');
+});
+`},
+		{Path: "routes/users.js", Contents: `const express = require('express');
+const router = express.Router();
+
+router.get('/:id', auth, getUser);
+router.delete("/:id", removeUser);
+
+module.exports = router;
+`},
+	}
+
+	routes := Extract(files)
+	expected := "GET /\nGET /api/users/:id\nDELETE /api/users/:id"
+	if endpoints(routes) != expected {
+		t.Fatalf("Expected the routes:\n%s\nbut got:\n%s", expected, endpoints(routes))
+	}
+	if routes[1].Handler != "getUser" || routes[1].File != "routes/users.js" {
+		t.Errorf("Unexpected route: %+v", routes[1])
+	}
+	if !strings.Contains(routes[0].Source, "res.send('ok')") {
+		t.Errorf("Expected the inline handler to be the source, but got %q", routes[0].Source)
+	}
+}
+
+func TestExtractPython(t *testing.T) {
+	files := []prompt.GitFile{
+		{Path: "app/main.py", Contents: `from fastapi import FastAPI
+from .routers import items
+
+app = FastAPI()
+app.include_router(items.router, prefix="/api")
+
+@app.get("/")
+async def root():
+    return {}
+`},
+		{Path: "app/routers/items.py", Contents: `from fastapi import APIRouter
+
+router = APIRouter(prefix="/items")
+
+@router.get("/{item_id}")
+async def read_item(item_id: int):
+    return {"id": item_id}
+`},
+		{Path: "flaskapp/views.py", Contents: `from flask import Blueprint
+
+bp = Blueprint("users", __name__, url_prefix="/users")
+
+@bp.route("/<int:id>", methods=["GET", "POST"])
+def user(id):
+    return ""
+`},
+	}
+
+	routes := Extract(files)
+	expected := "GET /\nGET /api/items/{item_id}\nGET /users/<int:id>\nPOST /users/<int:id>"
+	if endpoints(routes) != expected {
+		t.Fatalf("Expected the routes:\n%s\nbut got:\n%s", expected, endpoints(routes))
+	}
+
+	ResolveHandlers(routes, NewIndex(files))
+	if routes[1].Handler != "read_item" || !strings.Contains(routes[1].Source, `return {"id": item_id}`) {
+		t.Errorf("Expected the handler to be resolved, but got %+v", routes[1])
+	}
+}
+
+func TestContext(t *testing.T) {
+	files := []prompt.GitFile{
+		{Path: "api/routes.go", Contents: `package api
+
+import "github.com/labstack/echo/v4"
+
+func Register(e *echo.Echo) {
+	e.POST("/users", CreateUser)
+}
+
+// CreateUser creates a user
+func CreateUser(c echo.Context) error {
+	var req CreateUserRequest
+	c.Bind(&req)
+	return c.JSON(201, save(req))
+}
+
+func save(req CreateUserRequest) User {
+	return User{Name: req.Name}
+}
+`},
+		{Path: "api/types.go", Contents: `package api
+
+type CreateUserRequest struct {
+	Name    string
+	Address Address
+}
+
+type Address struct {
+	City string
+}
+
+type User struct {
+	Name string
+}
+
+type Unrelated struct{}
+`},
+	}
+
+	index := NewIndex(files)
+	routes := Extract(files)
+	ResolveHandlers(routes, index)
+
+	ctx := &Context{Index: index, Extra: []prompt.GitFile{{Path: "README.md", Contents: "# API"}}, Budget: 1000}
+	parts, truncated := ctx.For(routes[0])
+	joined := strings.Join(parts, "")
+	if truncated {
+		t.Error("Expected everything to fit in the budget")
+	}
+	for _, expected := range []string{"# api/routes.go:10 (handler of POST /users, registered at api/routes.go:6)", "# README.md", "type CreateUserRequest struct", "type Address struct", "type User struct", "func save("} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("Expected the context to contain %q, but got:\n%s", expected, joined)
+		}
+	}
+	if strings.Contains(joined, "Unrelated") {
+		t.Errorf("Expected the context to only contain referenced declarations, but got:\n%s", joined)
+	}
+
+	ctx.Budget = 1
+	parts, truncated = ctx.For(routes[0])
+	if !truncated || len(parts) != 1 {
+		t.Errorf("Expected only the handler within a tiny budget, but got %d parts", len(parts))
+	}
+}
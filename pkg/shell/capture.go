@@ -0,0 +1,262 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the shell integration records the last command run in an interactive shell,
+// its exit code and the end of what it wrote to stderr, so otto fix can see them
+
+// Shells are the shells the integration supports
+var Shells = []string{"bash", "zsh", "fish"}
+
+// DefaultMaxStderr is how many bytes of stderr are kept by default
+const DefaultMaxStderr = 4000
+
+// ErrNotRecorded is returned when no command has been recorded yet
+var ErrNotRecorded = errors.New("no command has been recorded, set up the shell integration with `otto shell init`")
+
+// LastCommand is the last command recorded by the shell integration
+type LastCommand struct {
+	Command  string
+	ExitCode int
+	// directory the command was run in
+	Dir string
+	// the end of the stderr of the command, empty if it wasn't captured
+	Stderr string
+	Time   time.Time
+}
+
+// CaptureDir returns the directory the shell integration records commands in
+func CaptureDir() (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(usr.HomeDir, ".ottodocs", "shell"), nil
+}
+
+// LoadLastCommand loads the last command recorded in dir
+func LoadLastCommand(dir string) (*LastCommand, error) {
+	// the status is written last, so the command is complete once it exists
+	info, err := os.Stat(filepath.Join(dir, "status"))
+	if os.IsNotExist(err) {
+		return nil, ErrNotRecorded
+	} else if err != nil {
+		return nil, err
+	}
+
+	read := func(name string) (string, error) {
+		contents, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return string(contents), err
+	}
+
+	last := &LastCommand{Time: info.ModTime()}
+	status, err := read("status")
+	if err != nil {
+		return nil, err
+	}
+	last.ExitCode, err = strconv.Atoi(strings.TrimSpace(status))
+	if err != nil {
+		return nil, fmt.Errorf("invalid exit code %q: %w", strings.TrimSpace(status), err)
+	}
+
+	command, err := read("command")
+	if err != nil {
+		return nil, err
+	}
+	last.Command = strings.TrimSpace(command)
+
+	wd, err := read("dir")
+	if err != nil {
+		return nil, err
+	}
+	last.Dir = strings.TrimSpace(wd)
+
+	stderr, err := read("stderr")
+	if err != nil {
+		return nil, err
+	}
+	// the tail may start in the middle of a character
+	last.Stderr = cleanOutput(strings.ToValidUTF8(stderr, ""))
+
+	return last, nil
+}
+
+var escapeRegex = regexp.MustCompile(`\x1b(\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[@-Z\\-_])`)
+
+// cleanOutput removes terminal escape sequences and the text overwritten by
+// carriage returns, like colors and progress bars, from captured output
+func cleanOutput(output string) string {
+	output = escapeRegex.ReplaceAllString(output, "")
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if j := strings.LastIndex(line, "\r"); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = line
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// InitScript returns the script that sets up the integration for the shell.
+// It records commands in dir and keeps at most maxStderr bytes of stderr.
+func InitScript(shell, dir string, maxStderr int) (string, error) {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashScript
+	case "zsh":
+		script = zshScript
+	case "fish":
+		script = fishScript
+	default:
+		return "", fmt.Errorf("unsupported shell %q, supported shells are %s", shell, strings.Join(Shells, ", "))
+	}
+	if maxStderr < 1 {
+		return "", errors.New("the stderr limit must be at least 1 byte")
+	}
+	r := strings.NewReplacer("@DIR@", quote(dir), "@MAX_STDERR@", strconv.Itoa(maxStderr))
+	return r.Replace(script), nil
+}
+
+// quote single quotes s for bash, zsh and fish
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shared by bash and zsh. Commands that run otto aren't recorded so otto fix
+// can be run more than once.
+const posixRecord = `__otto_record() {
+  case "$2" in otto|otto\ *) return ;; esac
+  printf '%s\n' "$2" > "$__otto_dir/command"
+  printf '%s\n' "$PWD" > "$__otto_dir/dir"
+  if [ -n "$__otto_stderr" ]; then
+    tail -c "$__otto_max_stderr" "$__otto_stderr" > "$__otto_dir/stderr"
+  else
+    : > "$__otto_dir/stderr"
+  fi
+  printf '%s\n' "$1" > "$__otto_dir/status"
+}
+`
+
+// stderr is copied to a file per shell with tee, so it still reaches the
+// terminal. The file is emptied before each command runs.
+const posixCapture = `__otto_dir=@DIR@
+__otto_max_stderr=@MAX_STDERR@
+__otto_stderr=
+mkdir -p "$__otto_dir"
+if [ "${OTTO_CAPTURE_STDERR:-1}" != 0 ] && [ -t 2 ]; then
+  __otto_stderr="$__otto_dir/stderr.$$"
+  : > "$__otto_stderr"
+  exec 2> >(tee -a "$__otto_stderr" >&2)
+fi
+`
+
+const bashScript = `# otto shell integration for bash 4.4 or newer. Add this to ~/.bashrc:
+#   eval "$(otto shell init bash)"
+if [ -z "$__otto_loaded" ]; then
+__otto_loaded=1
+` + posixCapture + posixRecord + `
+__otto_history() {
+  local entry
+  entry=$(HISTTIMEFORMAT= builtin history 1)
+  if [[ $entry =~ ^\ *([0-9]+)\*?\ +(.*)$ ]]; then
+    __otto_histnum=${BASH_REMATCH[1]}
+    __otto_histcmd=${BASH_REMATCH[2]}
+  fi
+}
+
+__otto_precmd() {
+  local st=$? last=$__otto_histnum
+  __otto_history
+  # the history number only changes when a command was run
+  if [ -n "$__otto_histnum" ] && [ "$__otto_histnum" != "$last" ]; then
+    __otto_record "$st" "$__otto_histcmd"
+  fi
+  return $st
+}
+
+__otto_preexec() {
+  if [ -n "$__otto_stderr" ]; then
+    : > "$__otto_stderr"
+  fi
+}
+
+__otto_history
+PROMPT_COMMAND="__otto_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+# PS0 is expanded after a command is read and before it runs
+PS0='$(__otto_preexec)'"${PS0:-}"
+if [ -n "$__otto_stderr" ] && [ -z "$(trap -p EXIT)" ]; then
+  trap 'rm -f "$__otto_stderr"' EXIT
+fi
+fi
+`
+
+const zshScript = `# otto shell integration for zsh. Add this to ~/.zshrc:
+#   eval "$(otto shell init zsh)"
+if [ -z "$__otto_loaded" ]; then
+__otto_loaded=1
+` + posixCapture + posixRecord + `
+__otto_preexec() {
+  __otto_command=$1
+  if [ -n "$__otto_stderr" ]; then
+    : > "$__otto_stderr"
+  fi
+}
+
+__otto_precmd() {
+  local st=$?
+  if [ -n "$__otto_command" ]; then
+    __otto_record "$st" "$__otto_command"
+    __otto_command=
+  fi
+  return $st
+}
+
+__otto_cleanup() {
+  [ -n "$__otto_stderr" ] && rm -f "$__otto_stderr"
+}
+
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec __otto_preexec
+add-zsh-hook precmd __otto_precmd
+add-zsh-hook zshexit __otto_cleanup
+fi
+`
+
+// fish can't redirect its own stderr, so only the command and exit code are recorded
+const fishScript = `# otto shell integration for fish. Add this to ~/.config/fish/config.fish:
+#   otto shell init fish | source
+# fish can't capture stderr, so only the command and its exit code are recorded.
+set -g __otto_dir @DIR@
+mkdir -p $__otto_dir
+
+function __otto_postexec --on-event fish_postexec
+    set -l st $status
+    set -l cmd $argv[1]
+    if test -z "$cmd"
+        return
+    end
+    switch $cmd
+        case otto 'otto *'
+            return
+    end
+    printf '%s\n' $cmd >$__otto_dir/command
+    printf '%s\n' $PWD >$__otto_dir/dir
+    printf '' >$__otto_dir/stderr
+    printf '%s\n' $st >$__otto_dir/status
+end
+`
@@ -0,0 +1,94 @@
+package shell
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadLastCommand(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadLastCommand(dir); !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("expected ErrNotRecorded, got %v", err)
+	}
+
+	files := map[string]string{
+		"command": "go biuld ./...\n",
+		"dir":     "/home/user/project\n",
+		"stderr":  "\x1b[?2004l\r\x1b[31mgo biuld: unknown command\x1b[0m\nloading 10%\rloading 100%\r\n",
+		"status":  "2\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	last, err := LoadLastCommand(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Command != "go biuld ./..." || last.ExitCode != 2 || last.Dir != "/home/user/project" {
+		t.Errorf("unexpected command: %+v", last)
+	}
+	if want := "go biuld: unknown command\nloading 100%"; last.Stderr != want {
+		t.Errorf("Stderr = %q, want %q", last.Stderr, want)
+	}
+}
+
+func TestInitScript(t *testing.T) {
+	for _, sh := range Shells {
+		script, err := InitScript(sh, "/home/o'brien/.ottodocs/shell", 100)
+		if err != nil {
+			t.Fatalf("%s: %v", sh, err)
+		}
+		if !strings.Contains(script, `'/home/o'\''brien/.ottodocs/shell'`) || strings.Contains(script, "@DIR@") {
+			t.Errorf("%s: the directory isn't quoted in the script", sh)
+		}
+	}
+
+	if _, err := InitScript("tcsh", "/tmp", 100); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	var bash []string
+	for i := 0; i < 50; i++ {
+		bash = append(bash, "echo "+strconv.Itoa(i))
+	}
+	if err := os.WriteFile(filepath.Join(home, BASH_HISTORY_PATH), []byte(strings.Join(bash, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	history, err := GetHistory(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 20 || history[0] != "echo 30" || history[19] != "echo 49" {
+		t.Errorf("unexpected bash history: %q", history)
+	}
+
+	zsh := ": 1700000000:0;make\n: 1700000001:0;make test\n: 1700000002:0;git status\n"
+	if err := os.WriteFile(filepath.Join(home, ZSH_HISTORY_PATH), []byte(zsh), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the most recently modified history is used
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(home, ZSH_HISTORY_PATH), future, future); err != nil {
+		t.Fatal(err)
+	}
+	history, err = GetHistory(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(history, ",") != "make test,git status" {
+		t.Errorf("unexpected zsh history: %q", history)
+	}
+}
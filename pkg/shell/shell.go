@@ -18,7 +18,7 @@ const BASH_HISTORY_PATH = ".bash_history"
 // GetShellHistory gets the most recently used command from the shell history
 // file. It will attempt to open all of them, only getting the most recently
 // modified one. Only get n lines. If the history file is zsh, it will just get
-// the command and not the metadata.
+// the command and not the metadata. The commands are returned oldest first.
 func GetHistory(n int) ([]string, error) {
 	shellHistories := []string{ZSH_HISTORY_PATH, BASH_HISTORY_PATH}
 	var mostRecentHistory string
@@ -84,13 +84,19 @@ func GetHistory(n int) ([]string, error) {
 				break
 			}
 		}
+		// put the commands back in the order they were run
+		utils.ReverseSlice(finalLines)
 	}
 
 	if shellUsed == BASH_HISTORY_PATH {
-		// just get the last 100 lines
-		finalLines = strings.Split(string(contents), "\n")
+		// just get the last n lines
+		for _, line := range strings.Split(string(contents), "\n") {
+			if line != "" {
+				finalLines = append(finalLines, line)
+			}
+		}
 		if len(finalLines) > n {
-			finalLines = finalLines[len(finalLines)-100:]
+			finalLines = finalLines[len(finalLines)-n:]
 		}
 	}
 
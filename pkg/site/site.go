@@ -0,0 +1,249 @@
+package site
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// package for laying out markdown documentation as a site that can be built with mkdocs
+
+// DocsDir is the directory in the site the pages are written to
+const DocsDir = "docs"
+
+// FileDoc is the markdown documentation of a file
+type FileDoc struct {
+	// slash separated path of the file relative to the repository root
+	Path     string
+	Markdown string
+}
+
+// a page documents the files in a directory
+type page struct {
+	dir      string
+	files    []FileDoc
+	children []string
+}
+
+var codeRefRegex = regexp.MustCompile("(\\[?)`([^`\\s]+)`")
+
+// Build lays out the documentation as one page per directory, mirroring the repository, with
+// an index page and an mkdocs.yml whose navigation follows the tree. Returns the contents of
+// each file of the site by its slash separated path relative to the site directory.
+func Build(name string, docs []FileDoc) map[string]string {
+	pages := map[string]*page{}
+	var getPage func(dir string) *page
+	getPage = func(dir string) *page {
+		if p, ok := pages[dir]; ok {
+			return p
+		}
+		p := &page{dir: dir}
+		pages[dir] = p
+		if dir != "." {
+			parent := getPage(path.Dir(dir))
+			parent.children = append(parent.children, dir)
+		}
+		return p
+	}
+
+	// the page each file is on, used for cross-links
+	files := map[string]string{}
+	for _, doc := range docs {
+		dir := path.Dir(doc.Path)
+		p := getPage(dir)
+		p.files = append(p.files, doc)
+		files[doc.Path] = dir
+	}
+	getPage(".")
+
+	for _, p := range pages {
+		sort.Strings(p.children)
+		sort.Slice(p.files, func(i, j int) bool {
+			return p.files[i].Path < p.files[j].Path
+		})
+	}
+
+	site := map[string]string{}
+	for dir, p := range pages {
+		site[path.Join(DocsDir, pagePath(dir))] = renderPage(name, p, pages, files)
+	}
+	site["mkdocs.yml"] = mkdocs(name, pages)
+
+	return site
+}
+
+// the path of the page for the directory, relative to the docs directory
+func pagePath(dir string) string {
+	if dir == "." {
+		return "index.md"
+	}
+	return dir + "/index.md"
+}
+
+// a relative link from the page of one directory to the page of another
+func link(from, to, anchor string) string {
+	target := pagePath(to)
+	if from != "." {
+		target = strings.Repeat("../", strings.Count(from, "/")+1) + target
+	}
+	if anchor != "" {
+		target += "#" + anchor
+	}
+	return target
+}
+
+// the anchor of the section documenting the file
+func anchor(filePath string) string {
+	return "file-" + strings.NewReplacer("/", "-", ".", "-").Replace(path.Base(filePath))
+}
+
+func renderPage(name string, p *page, pages map[string]*page, files map[string]string) string {
+	var b strings.Builder
+
+	if p.dir == "." {
+		fmt.Fprintf(&b, "# %s\n\n", name)
+	} else {
+		fmt.Fprintf(&b, "# %s\n\n", p.dir)
+
+		// breadcrumbs back up the tree
+		crumbs := []string{fmt.Sprintf("[%s](%s)", name, link(p.dir, ".", ""))}
+		parts := strings.Split(p.dir, "/")
+		for i := range parts[:len(parts)-1] {
+			dir := strings.Join(parts[:i+1], "/")
+			crumbs = append(crumbs, fmt.Sprintf("[%s](%s)", parts[i], link(p.dir, dir, "")))
+		}
+		crumbs = append(crumbs, parts[len(parts)-1])
+		b.WriteString(strings.Join(crumbs, " / ") + "\n\n")
+	}
+
+	if p.dir == "." && len(pages) > 1 {
+		b.WriteString("## Packages\n\n")
+		var list func(dir string, depth int)
+		list = func(dir string, depth int) {
+			for _, child := range pages[dir].children {
+				fmt.Fprintf(&b, "%s- [%s](%s)\n", strings.Repeat("  ", depth), child, link(p.dir, child, ""))
+				list(child, depth+1)
+			}
+		}
+		list(".", 0)
+		b.WriteString("\n")
+	} else if len(p.children) > 0 {
+		b.WriteString("## Packages\n\n")
+		for _, child := range p.children {
+			fmt.Fprintf(&b, "- [%s](%s)\n", path.Base(child), link(p.dir, child, ""))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(p.files) > 0 {
+		b.WriteString("## Files\n\n")
+		for _, file := range p.files {
+			fmt.Fprintf(&b, "- [%s](#%s)\n", path.Base(file.Path), anchor(file.Path))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, file := range p.files {
+		fmt.Fprintf(&b, "<a id=\"%s\"></a>\n\n## %s\n\n", anchor(file.Path), path.Base(file.Path))
+		b.WriteString(crossLink(demote(file.Markdown), p.dir, files, pages))
+		b.WriteString("\n\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// demote drops the first top level heading, the page adds its own for the file,
+// and moves the other headings down a level so they sit under it
+func demote(markdown string) string {
+	lines := strings.Split(strings.TrimSpace(markdown), "\n")
+	var out []string
+	inCode := false
+	droppedTitle := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+		}
+		if !inCode && strings.HasPrefix(line, "#") {
+			if !droppedTitle && strings.HasPrefix(line, "# ") {
+				droppedTitle = true
+				continue
+			}
+			if strings.HasPrefix(line, "######") {
+				line = "**" + strings.TrimSpace(strings.TrimLeft(line, "#")) + "**"
+			} else {
+				line = "#" + line
+			}
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// crossLink turns inline code that names another file or directory of the repository into a link to its page
+func crossLink(markdown, dir string, files map[string]string, pages map[string]*page) string {
+	lines := strings.Split(markdown, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		if inCode {
+			continue
+		}
+
+		lines[i] = codeRefRegex.ReplaceAllStringFunc(line, func(match string) string {
+			groups := codeRefRegex.FindStringSubmatch(match)
+			// already a link
+			if groups[1] != "" {
+				return match
+			}
+
+			ref := strings.TrimSuffix(strings.TrimPrefix(groups[2], "./"), "/")
+			if fileDir, ok := files[ref]; ok {
+				return fmt.Sprintf("[`%s`](%s)", groups[2], link(dir, fileDir, anchor(ref)))
+			}
+			// files in the same directory are often named without their path
+			if fileDir, ok := files[path.Join(dir, ref)]; ok && !strings.Contains(ref, "/") {
+				return fmt.Sprintf("[`%s`](#%s)", groups[2], anchor(path.Join(fileDir, ref)))
+			}
+			if _, ok := pages[ref]; ok && ref != "." && ref != dir {
+				return fmt.Sprintf("[`%s`](%s)", groups[2], link(dir, ref, ""))
+			}
+			return match
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mkdocs creates the mkdocs config, with the navigation sidebar following the directory tree
+func mkdocs(name string, pages map[string]*page) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "site_name: %s\n", strconv.Quote(name))
+	fmt.Fprintf(&b, "docs_dir: %s\n", DocsDir)
+	b.WriteString("theme: readthedocs\n")
+	b.WriteString("nav:\n")
+	b.WriteString("  - Home: index.md\n")
+
+	var nav func(dir string, depth int)
+	nav = func(dir string, depth int) {
+		indent := strings.Repeat("  ", depth)
+		for _, child := range pages[dir].children {
+			title := strconv.Quote(path.Base(child))
+			if len(pages[child].children) == 0 {
+				fmt.Fprintf(&b, "%s- %s: %s\n", indent, title, pagePath(child))
+				continue
+			}
+			// directories with subdirectories become sections, with their own page first
+			fmt.Fprintf(&b, "%s- %s:\n", indent, title)
+			fmt.Fprintf(&b, "%s  - Overview: %s\n", indent, pagePath(child))
+			nav(child, depth+1)
+		}
+	}
+	nav(".", 1)
+
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package site
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	docs := []FileDoc{
+		{Path: "main.go", Markdown: "# main.go\n\nStarts the `pkg/ai` client.\n\n## main\n\nRuns it."},
+		{Path: "pkg/ai/req.go", Markdown: "# req.go\n\nUsed by `main.go` and `api.go`.\n\n```go\n# not a heading\n```"},
+		{Path: "pkg/ai/api.go", Markdown: "# api.go\n\nCalls the API."},
+	}
+
+	site := Build("example", docs)
+
+	expectedFiles := []string{"docs/index.md", "docs/pkg/index.md", "docs/pkg/ai/index.md", "mkdocs.yml"}
+	if len(site) != len(expectedFiles) {
+		t.Errorf("Expected %d files, but got %d", len(expectedFiles), len(site))
+	}
+	for _, file := range expectedFiles {
+		if _, ok := site[file]; !ok {
+			t.Errorf("Expected the site to contain %s", file)
+		}
+	}
+
+	index := site["docs/index.md"]
+	for _, expected := range []string{
+		"# example\n",
+		"- [pkg/ai](pkg/ai/index.md)",
+		"## main.go",
+		"### main\n",
+		"[`pkg/ai`](pkg/ai/index.md)",
+	} {
+		if !strings.Contains(index, expected) {
+			t.Errorf("Expected the index to contain %q, but got:\n%s", expected, index)
+		}
+	}
+
+	ai := site["docs/pkg/ai/index.md"]
+	for _, expected := range []string{
+		"[example](../../index.md) / [pkg](../../pkg/index.md) / ai",
+		"[`main.go`](../../index.md#file-main-go)",
+		"[`api.go`](#file-api-go)",
+		"# not a heading",
+	} {
+		if !strings.Contains(ai, expected) {
+			t.Errorf("Expected the page to contain %q, but got:\n%s", expected, ai)
+		}
+	}
+	// files are in order and each has a single heading
+	if strings.Index(ai, "## api.go") > strings.Index(ai, "## req.go") || strings.Count(ai, "req.go\n") != 1 {
+		t.Errorf("Expected one section per file in order, but got:\n%s", ai)
+	}
+
+	expectedNav := `nav:
+  - Home: index.md
+  - "pkg":
+    - Overview: pkg/index.md
+    - "ai": pkg/ai/index.md
+`
+	if !strings.HasSuffix(site["mkdocs.yml"], expectedNav) {
+		t.Errorf("Expected the nav:\n%s\nbut got:\n%s", expectedNav, site["mkdocs.yml"])
+	}
+}
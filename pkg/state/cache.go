@@ -0,0 +1,44 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const cacheFileName = "cache.json"
+
+// Cache maps file paths relative to the repository root to the hash of
+// their contents when they were last documented
+type Cache map[string]string
+
+// LoadCache loads the documentation cache for the repository at root
+func LoadCache(root string) (Cache, error) {
+	cache := Cache{}
+	err := Load(root, cacheFileName, &cache)
+	if err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// Save saves the documentation cache for the repository at root
+func (c Cache) Save(root string) error {
+	return Save(root, cacheFileName, c)
+}
+
+// Unchanged reports whether the file contents are the same as when it was last documented
+func (c Cache) Unchanged(path, contents string) bool {
+	hash, ok := c[path]
+	return ok && hash == HashContents(contents)
+}
+
+// Set records the contents the file was documented with
+func (c Cache) Set(path, contents string) {
+	c[path] = HashContents(contents)
+}
+
+// HashContents returns the SHA-256 hash of the contents
+func HashContents(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
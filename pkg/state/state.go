@@ -0,0 +1,82 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/git"
+)
+
+// DirName is the directory in the root of a repository where Otto keeps its state
+const DirName = ".ottodocs"
+
+// Root finds the root of the repository containing path by looking for a .git
+// directory. If there is none, the directory of path is used.
+func Root(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(abs)
+	if err == nil && !info.IsDir() {
+		abs = filepath.Dir(abs)
+	}
+
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if git.IsGitRepo(dir) {
+			return dir, nil
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	return abs, nil
+}
+
+// RelPath returns the path relative to the root with forward slashes, for use as a key in state files
+func RelPath(root, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// Load decodes the JSON state file with the given name into v.
+// If the file does not exist v is left untouched.
+func Load(root, name string, v interface{}) error {
+	file, err := os.Open(filepath.Join(root, DirName, name))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewDecoder(file).Decode(v)
+}
+
+// Save encodes v as JSON into the state file with the given name
+func Save(root, name string, v interface{}) error {
+	dir := filepath.Join(root, DirName)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	contents, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, name), append(contents, '\n'), 0644)
+}
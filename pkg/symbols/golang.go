@@ -0,0 +1,117 @@
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// ParseGo finds the top level declarations in Go source code using go/ast
+func ParseGo(contents string) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", contents, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(contents, "\n")
+	line := func(pos token.Pos) int {
+		return fset.Position(pos).Line
+	}
+	indent := func(l int) string {
+		if l < 1 || l > len(lines) {
+			return ""
+		}
+		return leadingWhitespace(lines[l-1])
+	}
+	setDoc := func(sym *Symbol, doc *ast.CommentGroup) {
+		if doc == nil {
+			return
+		}
+		sym.DocStart = line(doc.Pos())
+		sym.DocEnd = line(doc.End())
+	}
+
+	var syms []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sym := Symbol{
+				Name:      d.Name.Name,
+				Kind:      "func",
+				Line:      line(d.Pos()),
+				StartLine: line(d.Pos()),
+				EndLine:   line(d.End()),
+				Exported:  d.Name.IsExported(),
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sym.Kind = "method"
+				sym.Receiver = receiverName(d.Recv.List[0].Type)
+				sym.Exported = sym.Exported && ast.IsExported(sym.Receiver)
+			}
+			setDoc(&sym, d.Doc)
+			syms = append(syms, sym)
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			grouped := d.Lparen.IsValid()
+			for _, spec := range d.Specs {
+				var names []*ast.Ident
+				var doc *ast.CommentGroup
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names = []*ast.Ident{s.Name}
+					doc = s.Doc
+				case *ast.ValueSpec:
+					names = s.Names
+					doc = s.Doc
+				}
+				if len(names) == 0 {
+					continue
+				}
+
+				sym := Symbol{
+					Name:     names[0].Name,
+					Kind:     d.Tok.String(),
+					Exported: names[0].IsExported(),
+				}
+				if grouped {
+					sym.Line = line(spec.Pos())
+					sym.EndLine = line(spec.End())
+					sym.GroupDoc = d.Doc != nil
+					setDoc(&sym, doc)
+				} else {
+					sym.Line = line(d.Pos())
+					sym.EndLine = line(d.End())
+					setDoc(&sym, d.Doc)
+				}
+				sym.StartLine = sym.Line
+				sym.Indent = indent(sym.Line)
+				syms = append(syms, sym)
+			}
+		}
+	}
+
+	sort.SliceStable(syms, func(i, j int) bool {
+		return syms[i].Line < syms[j].Line
+	})
+
+	return syms, nil
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
@@ -0,0 +1,497 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// the heuristic parser is a line based parser that finds declarations using regular
+// expressions. It doesn't need a grammar for every language, but it can be fooled
+// by declarations split across lines or hidden inside strings.
+
+type pattern struct {
+	kind string
+	// must contain a group called "name"
+	re *regexp.Regexp
+}
+
+type language struct {
+	name string
+	// line comment prefixes that make up a doc comment above a declaration
+	lineComments []string
+	// block comment delimiters, empty if the language has none
+	blockStart string
+	blockEnd   string
+	// lines above a declaration that belong to it, like decorators and attributes
+	decorator *regexp.Regexp
+	// whether blocks are delimited by braces. If false, indentation is used.
+	braces bool
+	// whether declarations are documented with a string at the start of their body
+	docstrings bool
+	patterns   []pattern
+	exported   func(name, line string) bool
+}
+
+var classKinds = map[string]bool{
+	"class":     true,
+	"interface": true,
+	"struct":    true,
+	"trait":     true,
+	"impl":      true,
+	"module":    true,
+	"object":    true,
+	"contract":  true,
+}
+
+func p(kind, re string) pattern {
+	return pattern{kind: kind, re: regexp.MustCompile(re)}
+}
+
+func notUnderscored(name, line string) bool {
+	return !strings.HasPrefix(name, "_")
+}
+
+func notPrivate(name, line string) bool {
+	return !strings.HasPrefix(name, "_") && !strings.Contains(line, "private ")
+}
+
+var python = &language{
+	name:         "python",
+	lineComments: []string{"#"},
+	decorator:    regexp.MustCompile(`^@`),
+	docstrings:   true,
+	patterns: []pattern{
+		p("func", `^\s*(?:async\s+)?def\s+(?P<name>\w+)\s*\(`),
+		p("class", `^\s*class\s+(?P<name>\w+)`),
+	},
+	exported: notUnderscored,
+}
+
+var javascript = &language{
+	name:         "javascript",
+	lineComments: []string{"//"},
+	blockStart:   "/*",
+	blockEnd:     "*/",
+	decorator:    regexp.MustCompile(`^@`),
+	braces:       true,
+	patterns: []pattern{
+		p("func", `^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>\w+)\s*[<(]`),
+		p("class", `^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(?P<name>\w+)`),
+		p("interface", `^\s*(?:export\s+)?interface\s+(?P<name>\w+)`),
+		p("type", `^\s*(?:export\s+)?type\s+(?P<name>\w+)\s*(?:<[^>]*>)?\s*=`),
+		p("enum", `^\s*(?:export\s+)?(?:const\s+)?enum\s+(?P<name>\w+)`),
+		p("func", `^\s*(?:export\s+)?(?:const|let|var)\s+(?P<name>\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|\w+\s*=>)`),
+		p("method", `^\s+(?:(?:public|private|protected|static|async|readonly|override|get|set)\s+)*(?P<name>[A-Za-z_$][\w$]*)\s*(?:<[^>]*>)?\([^)]*\)?\s*(?::[^{]+)?\{\s*$`),
+	},
+	exported: func(name, line string) bool {
+		return notPrivate(name, line) && !strings.HasPrefix(name, "#")
+	},
+}
+
+var java = &language{
+	name:         "java",
+	lineComments: []string{"//"},
+	blockStart:   "/*",
+	blockEnd:     "*/",
+	decorator:    regexp.MustCompile(`^(@|\[)`),
+	braces:       true,
+	patterns: []pattern{
+		p("class", `^\s*(?:(?:public|private|protected|internal|static|final|abstract|sealed|partial|open|data)\s+)*(?:class|interface|enum|record|struct)\s+(?P<name>\w+)`),
+		p("method", `^\s*(?:(?:public|protected|private|internal|static|final|abstract|synchronized|native|default|override|virtual|async|sealed|extern|unsafe|partial)\s+)+(?:[\w<>\[\],.?]+\s+)?(?P<name>\w+)\s*\(`),
+	},
+	exported: notPrivate,
+}
+
+var kotlin = &language{
+	name:         "kotlin",
+	lineComments: []string{"//"},
+	blockStart:   "/*",
+	blockEnd:     "*/",
+	decorator:    regexp.MustCompile(`^@`),
+	braces:       true,
+	patterns: []pattern{
+		p("class", `^\s*(?:(?:public|private|protected|internal|open|abstract|sealed|data|enum|final|case)\s+)*(?:class|interface|object|trait)\s+(?P<name>\w+)`),
+		p("func", `^\s*(?:(?:public|private|protected|internal|open|override|suspend|inline|abstract|final|implicit)\s+)*(?:fun|def)\s+(?:<[^>]+>\s*)?(?:[\w.]+\.)?(?P<name>\w+)\s*[\[(:=]`),
+	},
+	exported: notPrivate,
+}
+
+var swift = &language{
+	name:         "swift",
+	lineComments: []string{"///", "//"},
+	blockStart:   "/*",
+	blockEnd:     "*/",
+	decorator:    regexp.MustCompile(`^@`),
+	braces:       true,
+	patterns: []pattern{
+		p("class", `^\s*(?:(?:public|private|fileprivate|internal|open|final)\s+)*(?:class|struct|enum|protocol|extension)\s+(?P<name>\w+)`),
+		p("func", `^\s*(?:(?:public|private|fileprivate|internal|open|override|static|class|mutating|final)\s+)*func\s+(?P<name>\w+)`),
+	},
+	exported: notPrivate,
+}
+
+var rust = &language{
+	name:         "rust",
+	lineComments: []string{"///", "//!", "//"},
+	blockStart:   "/*",
+	blockEnd:     "*/",
+	decorator:    regexp.MustCompile(`^#\[`),
+	braces:       true,
+	patterns: []pattern{
+		p("func", `^\s*(?:pub(?:\([\w:]+\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"\w+"\s+)?fn\s+(?P<name>\w+)`),
+		p("struct", `^\s*(?:pub(?:\([\w:]+\))?\s+)?struct\s+(?P<name>\w+)`),
+		p("enum", `^\s*(?:pub(?:\([\w:]+\))?\s+)?enum\s+(?P<name>\w+)`),
+		p("trait", `^\s*(?:pub(?:\([\w:]+\))?\s+)?(?:unsafe\s+)?trait\s+(?P<name>\w+)`),
+		p("type", `^\s*(?:pub(?:\([\w:]+\))?\s+)?type\s+(?P<name>\w+)`),
+		p("const", `^\s*(?:pub(?:\([\w:]+\))?\s+)?(?:const|static)\s+(?:mut\s+)?(?P<name>[A-Z_][A-Z0-9_]*)\s*:`),
+		p("module", `^\s*(?:pub(?:\([\w:]+\))?\s+)?mod\s+(?P<name>\w+)\s*\{`),
+	},
+	exported: func(name, line string) bool {
+		return strings.HasPrefix(strings.TrimSpace(line), "pub")
+	},
+}
+
+var clang = &language{
+	name:         "c",
+	lineComments: []string{"//"},
+	blockStart:   "/*",
+	blockEnd:     "*/",
+	braces:       true,
+	patterns: []pattern{
+		p("struct", `^(?:typedef\s+)?(?:struct|class|union|enum)\s+(?P<name>\w+)\s*(?::[^{]*)?\{?\s*$`),
+		p("func", `^(?:[\w:*&<>,]+\s+)+\**(?P<name>[\w:~]+)\s*\([^;]*$`),
+	},
+	exported: func(name, line string) bool {
+		return !strings.HasPrefix(line, "static ")
+	},
+}
+
+var ruby = &language{
+	name:         "ruby",
+	lineComments: []string{"#"},
+	patterns: []pattern{
+		p("func", `^\s*def\s+(?:self\.)?(?P<name>[\w?!=]+)`),
+		p("class", `^\s*(?:class|module)\s+(?P<name>[\w:]+)`),
+	},
+	exported: notUnderscored,
+}
+
+var php = &language{
+	name:         "php",
+	lineComments: []string{"//", "#"},
+	blockStart:   "/*",
+	blockEnd:     "*/",
+	braces:       true,
+	patterns: []pattern{
+		p("func", `^\s*(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+(?P<name>\w+)\s*\(`),
+		p("class", `^\s*(?:(?:abstract|final)\s+)?(?:class|interface|trait)\s+(?P<name>\w+)`),
+	},
+	exported: notPrivate,
+}
+
+var solidity = &language{
+	name:         "solidity",
+	lineComments: []string{"///", "//"},
+	blockStart:   "/*",
+	blockEnd:     "*/",
+	braces:       true,
+	patterns: []pattern{
+		p("func", `^\s*function\s+(?P<name>\w+)\s*\(`),
+		p("contract", `^\s*(?:abstract\s+)?(?:contract|library|interface)\s+(?P<name>\w+)`),
+		p("struct", `^\s*(?:struct|enum|event)\s+(?P<name>\w+)`),
+	},
+	exported: notPrivate,
+}
+
+var scripting = &language{
+	name:         "shell",
+	lineComments: []string{"#"},
+	braces:       true,
+	patterns: []pattern{
+		p("func", `^\s*function\s+(?P<name>[\w-]+)`),
+		p("func", `^\s*(?P<name>[\w-]+)\s*\(\)\s*\{?\s*$`),
+		p("func", `^\s*sub\s+(?P<name>\w+)`),
+	},
+	exported: notUnderscored,
+}
+
+var lua = &language{
+	name:         "lua",
+	lineComments: []string{"--"},
+	patterns: []pattern{
+		p("func", `^\s*(?:local\s+)?function\s+(?P<name>[\w.:]+)\s*\(`),
+		p("func", `^\s*(?:local\s+)?(?P<name>[\w.]+)\s*=\s*function\s*\(`),
+	},
+	exported: func(name, line string) bool {
+		return !strings.HasPrefix(strings.TrimSpace(line), "local ")
+	},
+}
+
+var rlang = &language{
+	name:         "r",
+	lineComments: []string{"#'", "#"},
+	braces:       true,
+	patterns: []pattern{
+		p("func", `^(?P<name>[\w.]+)\s*(?:<-|=)\s*function\s*\(`),
+	},
+	exported: notUnderscored,
+}
+
+var heuristicLanguages = map[string]*language{
+	".py":    python,
+	".js":    javascript,
+	".jsx":   javascript,
+	".mjs":   javascript,
+	".ts":    javascript,
+	".tsx":   javascript,
+	".java":  java,
+	".cs":    java,
+	".kt":    kotlin,
+	".kts":   kotlin,
+	".scala": kotlin,
+	".swift": swift,
+	".rs":    rust,
+	".c":     clang,
+	".h":     clang,
+	".cpp":   clang,
+	".rb":    ruby,
+	".php":   php,
+	".sol":   solidity,
+	".sh":    scripting,
+	".pl":    scripting,
+	".lua":   lua,
+	".r":     rlang,
+}
+
+var reservedNames = map[string]bool{
+	"if":       true,
+	"for":      true,
+	"while":    true,
+	"switch":   true,
+	"catch":    true,
+	"return":   true,
+	"function": true,
+	"else":     true,
+	"new":      true,
+	"do":       true,
+	"try":      true,
+	"sizeof":   true,
+}
+
+func parseHeuristic(lang *language, contents string) []Symbol {
+	lines := strings.Split(contents, "\n")
+
+	var syms []Symbol
+	inBlock := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// skip anything inside comments
+		if inBlock {
+			if strings.Contains(trimmed, lang.blockEnd) {
+				inBlock = false
+			}
+			continue
+		}
+		if lang.blockStart != "" && strings.HasPrefix(trimmed, lang.blockStart) {
+			inBlock = !strings.Contains(trimmed[len(lang.blockStart):], lang.blockEnd)
+			continue
+		}
+		if isLineComment(lang, trimmed) {
+			continue
+		}
+
+		for _, pat := range lang.patterns {
+			matches := pat.re.FindStringSubmatch(line)
+			if matches == nil {
+				continue
+			}
+			name := matches[pat.re.SubexpIndex("name")]
+			if reservedNames[name] {
+				continue
+			}
+
+			sym := Symbol{
+				Name:     name,
+				Kind:     pat.kind,
+				Line:     i + 1,
+				Indent:   leadingWhitespace(line),
+				Exported: lang.exported(name, trimmed),
+			}
+			sym.StartLine = decoratorStart(lang, lines, i) + 1
+			sym.EndLine = blockEnd(lang, lines, i) + 1
+			sym.DocStart, sym.DocEnd = docRange(lang, lines, sym.StartLine-1)
+			if lang.docstrings {
+				findDocstring(&sym, lines, i)
+			}
+
+			// methods are the functions nested inside a class
+			for j := len(syms) - 1; j >= 0; j-- {
+				parent := syms[j]
+				if classKinds[parent.Kind] && parent.EndLine >= sym.Line && len(parent.Indent) < len(sym.Indent) {
+					sym.Receiver = parent.Name
+					if sym.Kind == "func" {
+						sym.Kind = "method"
+					}
+					break
+				}
+			}
+
+			syms = append(syms, sym)
+			break
+		}
+	}
+
+	return syms
+}
+
+func isLineComment(lang *language, trimmed string) bool {
+	for _, prefix := range lang.lineComments {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the index of the first decorator line above the declaration at index i
+func decoratorStart(lang *language, lines []string, i int) int {
+	if lang.decorator == nil {
+		return i
+	}
+	start := i
+	for j := i - 1; j >= 0; j-- {
+		if !lang.decorator.MatchString(strings.TrimSpace(lines[j])) {
+			break
+		}
+		start = j
+	}
+	return start
+}
+
+// returns the 1-indexed line range of the comment directly above the line at index i
+func docRange(lang *language, lines []string, i int) (int, int) {
+	end := i - 1
+	if end < 0 {
+		return 0, 0
+	}
+
+	trimmed := strings.TrimSpace(lines[end])
+	if lang.blockEnd != "" && strings.HasSuffix(trimmed, lang.blockEnd) {
+		for j := end; j >= 0; j-- {
+			if strings.Contains(lines[j], lang.blockStart) {
+				return j + 1, end + 1
+			}
+		}
+		return 0, 0
+	}
+
+	start := -1
+	for j := end; j >= 0; j-- {
+		if !isLineComment(lang, strings.TrimSpace(lines[j])) {
+			break
+		}
+		start = j
+	}
+	if start == -1 {
+		return 0, 0
+	}
+	return start + 1, end + 1
+}
+
+// returns the index of the last line of the block starting at index i
+func blockEnd(lang *language, lines []string, i int) int {
+	if lang.braces {
+		depth := 0
+		opened := false
+		for j := i; j < len(lines); j++ {
+			for _, r := range lines[j] {
+				if r == '{' {
+					depth++
+					opened = true
+				} else if r == '}' {
+					depth--
+				}
+			}
+			if opened && depth <= 0 {
+				return j
+			}
+			// declarations without a body, like abstract methods or prototypes
+			if !opened && strings.HasSuffix(strings.TrimSpace(lines[j]), ";") {
+				return j
+			}
+		}
+		return i
+	}
+
+	indent := len(leadingWhitespace(lines[i]))
+	last := i
+	for j := i + 1; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == "" {
+			continue
+		}
+		if len(leadingWhitespace(lines[j])) <= indent {
+			// ruby and lua close blocks with "end" at the same indentation
+			if strings.TrimSpace(lines[j]) == "end" {
+				return j
+			}
+			break
+		}
+		last = j
+	}
+	return last
+}
+
+var docstringQuotes = []string{`"""`, `'''`}
+
+// sets the body and docstring fields of a symbol declared at index i
+func findDocstring(sym *Symbol, lines []string, i int) {
+	// the signature may span multiple lines, the body starts after the colon
+	j := i
+	for ; j < len(lines); j++ {
+		code := strings.TrimSpace(strings.SplitN(lines[j], "#", 2)[0])
+		if strings.HasSuffix(code, ":") {
+			break
+		}
+		// one line declarations like "def f(): pass" have no body to document
+		if strings.Contains(code, "):") || strings.Contains(code, "->") && strings.Contains(code, ":") {
+			return
+		}
+	}
+	if j >= len(lines) {
+		return
+	}
+
+	sym.BodyStart = j + 2
+	sym.BodyIndent = sym.Indent + "    "
+	for k := j + 1; k < len(lines); k++ {
+		trimmed := strings.TrimSpace(lines[k])
+		if trimmed == "" {
+			continue
+		}
+		sym.BodyStart = k + 1
+		if len(leadingWhitespace(lines[k])) > len(sym.Indent) {
+			sym.BodyIndent = leadingWhitespace(lines[k])
+		}
+
+		trimmed = strings.TrimLeft(trimmed, "rRbBuU")
+		for _, quote := range docstringQuotes {
+			if !strings.HasPrefix(trimmed, quote) {
+				continue
+			}
+			sym.DocstringStart = k + 1
+			if strings.Contains(trimmed[len(quote):], quote) {
+				sym.DocstringEnd = k + 1
+				return
+			}
+			for end := k + 1; end < len(lines); end++ {
+				if strings.Contains(lines[end], quote) {
+					sym.DocstringEnd = end + 1
+					return
+				}
+			}
+			sym.DocstringStart = 0
+		}
+		return
+	}
+}
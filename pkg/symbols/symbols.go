@@ -0,0 +1,99 @@
+package symbols
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Symbol is a declaration in a source file that can carry a doc comment
+type Symbol struct {
+	// Name of the declared identifier
+	Name string
+	// Receiver type for methods, empty otherwise
+	Receiver string
+	// func, method, type, var, const, class, interface, etc.
+	Kind string
+	// Line of the declaration itself. 1-indexed.
+	Line int
+	// First line of the declaration including decorators and attributes.
+	// Doc comments go above this line.
+	StartLine int
+	// Last line of the declaration. Equal to Line if the end could not be determined.
+	EndLine int
+	// Line range of the existing doc comment. Both 0 if there is none.
+	DocStart int
+	DocEnd   int
+	// Leading whitespace of the declaration
+	Indent string
+	// First line of the body and its leading whitespace. Only set for languages
+	// that put docstrings inside the body, like Python.
+	BodyStart  int
+	BodyIndent string
+	// Line range of the existing docstring inside the body. Both 0 if there is none.
+	DocstringStart int
+	DocstringEnd   int
+	// Whether the symbol is part of the public API
+	Exported bool
+	// Whether the symbol is in a grouped declaration, like Go's const ( ... ), whose
+	// doc comment documents the whole group
+	GroupDoc bool
+}
+
+// Key is the name used to refer to the symbol in prompts. Methods are qualified by their receiver.
+func (s *Symbol) Key() string {
+	if s.Receiver != "" {
+		return s.Receiver + "." + s.Name
+	}
+	return s.Name
+}
+
+// HasDoc reports whether the symbol already has a doc comment or docstring
+func (s *Symbol) HasDoc() bool {
+	return s.DocStart > 0 || s.DocstringStart > 0
+}
+
+// Overlaps reports whether the declaration or its doc comment touch any of the line ranges
+func (s *Symbol) Overlaps(ranges [][2]int) bool {
+	start := s.StartLine
+	if s.DocStart > 0 {
+		start = s.DocStart
+	}
+	for _, r := range ranges {
+		if r[0] <= s.EndLine && r[1] >= start {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse finds the symbols declared in the file. Go files are parsed with go/ast,
+// everything else uses a line based heuristic parser.
+func Parse(path, contents string) ([]Symbol, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		return ParseGo(contents)
+	}
+
+	lang, ok := heuristicLanguages[ext]
+	if !ok {
+		return nil, fmt.Errorf("the file type %s is not supported", ext)
+	}
+
+	return parseHeuristic(lang, contents), nil
+}
+
+// Exported returns only the exported symbols
+func Exported(syms []Symbol) []Symbol {
+	var exported []Symbol
+	for _, sym := range syms {
+		if sym.Exported {
+			exported = append(exported, sym)
+		}
+	}
+	return exported
+}
+
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
@@ -0,0 +1,103 @@
+package symbols
+
+import (
+	"testing"
+)
+
+const goSource = `package example
+
+import "fmt"
+
+// Config holds settings.
+type Config struct {
+	Name string
+}
+
+func (c *Config) Print() {
+	fmt.Println(c.Name)
+}
+
+func helper() {}
+
+const (
+	// Version of the thing
+	Version = "1.0"
+	internal = 1
+)
+`
+
+const pythonSource = `import os
+
+
+class Loader:
+    # Loads files
+    def load(self, path):
+        return open(path).read()
+
+    def _cache(self):
+        """
+        Caches files.
+        """
+        pass
+
+
+@decorator
+def run(args):
+    pass
+`
+
+func TestParseGo(t *testing.T) {
+	syms, err := Parse("example.go", goSource)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []Symbol{
+		{Name: "Config", Kind: "type", Line: 6, StartLine: 6, EndLine: 8, DocStart: 5, DocEnd: 5, Exported: true},
+		{Name: "Print", Receiver: "Config", Kind: "method", Line: 10, StartLine: 10, EndLine: 12, Exported: true},
+		{Name: "helper", Kind: "func", Line: 14, StartLine: 14, EndLine: 14},
+		{Name: "Version", Kind: "const", Line: 18, StartLine: 18, EndLine: 18, DocStart: 17, DocEnd: 17, Indent: "\t", Exported: true},
+		{Name: "internal", Kind: "const", Line: 19, StartLine: 19, EndLine: 19, Indent: "\t"},
+	}
+
+	if len(syms) != len(expected) {
+		t.Fatalf("Expected %d symbols, but got %d: %+v", len(expected), len(syms), syms)
+	}
+
+	for i, sym := range syms {
+		if sym != expected[i] {
+			t.Errorf("Expected symbol %+v, but got %+v", expected[i], sym)
+		}
+	}
+}
+
+func TestParseHeuristic(t *testing.T) {
+	syms, err := Parse("loader.py", pythonSource)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []Symbol{
+		{Name: "Loader", Kind: "class", Line: 4, StartLine: 4, EndLine: 13, BodyStart: 5, BodyIndent: "    ", Exported: true},
+		{Name: "load", Receiver: "Loader", Kind: "method", Line: 6, StartLine: 6, EndLine: 7, DocStart: 5, DocEnd: 5, Indent: "    ", BodyStart: 7, BodyIndent: "        ", Exported: true},
+		{Name: "_cache", Receiver: "Loader", Kind: "method", Line: 9, StartLine: 9, EndLine: 13, Indent: "    ", BodyStart: 10, BodyIndent: "        ", DocstringStart: 10, DocstringEnd: 12},
+		{Name: "run", Kind: "func", Line: 17, StartLine: 16, EndLine: 18, BodyStart: 18, BodyIndent: "    ", Exported: true},
+	}
+
+	if len(syms) != len(expected) {
+		t.Fatalf("Expected %d symbols, but got %d: %+v", len(expected), len(syms), syms)
+	}
+
+	for i, sym := range syms {
+		if sym != expected[i] {
+			t.Errorf("Expected symbol %+v, but got %+v", expected[i], sym)
+		}
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+	_, err := Parse("styles.css", "body {}")
+	if err == nil {
+		t.Errorf("Expected error, but got nil")
+	}
+}
@@ -0,0 +1,147 @@
+package testgen
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/utils"
+)
+
+// package for deciding where the tests for a file go, how they're run and what they should look like
+
+// Language is how a language writes and runs its tests
+type Language struct {
+	Name string
+	// returns the path of the test file for the source file
+	testPath func(p string) string
+	// returns the command that runs the tests in the test file
+	command func(testPath string) string
+	// how the tests should be written
+	instructions string
+}
+
+var jsExtensions = []string{".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs"}
+
+var goLanguage = Language{
+	Name: "go",
+	testPath: func(p string) string {
+		return strings.TrimSuffix(p, ".go") + "_test.go"
+	},
+	command: func(testPath string) string {
+		dir := path.Dir(testPath)
+		// go test treats paths without ./ as import paths
+		if !path.IsAbs(dir) && !filepath.IsAbs(dir) && dir != "." {
+			dir = "./" + dir
+		}
+		return "go test " + quote(dir)
+	},
+	instructions: "Write table-driven tests with the testing package: a slice of named cases run with t.Run. Put them in the same package as the code, so unexported functions can be tested.",
+}
+
+var pythonLanguage = Language{
+	Name: "python",
+	testPath: func(p string) string {
+		return path.Join(path.Dir(p), "test_"+path.Base(p))
+	},
+	command: func(testPath string) string {
+		return "python -m pytest " + quote(testPath)
+	},
+	instructions: "Write pytest tests. Use pytest.mark.parametrize for the cases of each function, and import the code under test from its module.",
+}
+
+var jsLanguage = Language{
+	Name: "javascript",
+	testPath: func(p string) string {
+		ext := path.Ext(p)
+		return strings.TrimSuffix(p, ext) + ".test" + ext
+	},
+	command: func(testPath string) string {
+		return "npx jest " + quote(testPath)
+	},
+	instructions: "Write Jest tests with describe and it. Use test.each for the cases of each function, and import the code under test with a relative path, the same way the project imports its modules.",
+}
+
+var rustLanguage = Language{
+	Name: "rust",
+	// unit tests go in a module at the end of the file they test
+	testPath: func(p string) string {
+		return p
+	},
+	command: func(string) string {
+		return "cargo test"
+	},
+	instructions: "Write the tests in a #[cfg(test)] mod tests module at the end of the file, with use super::*. Loop over a slice of cases for each function. Do not change the code outside the tests module.",
+}
+
+var safeArgRegex = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+// quote quotes the argument for the shell the tests are run with, if it needs it
+func quote(arg string) string {
+	if safeArgRegex.MatchString(arg) {
+		return arg
+	}
+	if runtime.GOOS == "windows" {
+		return `"` + arg + `"`
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// GetLanguage returns how tests are written for the file
+func GetLanguage(p string) (Language, error) {
+	if utils.IsTestFile(p) {
+		return Language{}, fmt.Errorf("%s is already a test file", p)
+	}
+	ext := strings.ToLower(path.Ext(p))
+	switch {
+	case ext == ".go":
+		return goLanguage, nil
+	case ext == ".py":
+		return pythonLanguage, nil
+	case utils.Contains(jsExtensions, ext):
+		return jsLanguage, nil
+	case ext == ".rs":
+		return rustLanguage, nil
+	}
+	return Language{}, fmt.Errorf("generating tests for %s files is not supported", ext)
+}
+
+// TestPath returns the slash separated path of the test file for the source file
+func (l Language) TestPath(p string) string {
+	return l.testPath(p)
+}
+
+// Command returns the command that runs the tests in the test file
+func (l Language) Command(testPath string) string {
+	return l.command(testPath)
+}
+
+var goPackageRegex = regexp.MustCompile(`(?m)^package\s+(\w+)`)
+
+// Goal describes the tests to write for the source file, or only for the function if one is given
+func (l Language) Goal(p, contents, function string) string {
+	target := "the exported functions and methods in " + p
+	if function != "" {
+		target = "the function " + function + " in " + p
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write unit tests for %s. %s", target, l.instructions)
+	if l.Name == "go" {
+		if match := goPackageRegex.FindStringSubmatch(contents); match != nil {
+			fmt.Fprintf(&b, " The package is %s.", match[1])
+		}
+	}
+	b.WriteString(" Cover the normal cases, edge cases like empty and invalid input, and the errors the code returns. Test the behavior the code has, not the behavior you think it should have, and only use the functions and types that exist in the code given to you. Do not use external test libraries the project doesn't already use.")
+
+	testPath := l.TestPath(p)
+	if testPath == p {
+		fmt.Fprintf(&b, " Add the tests to %s.", p)
+	} else {
+		fmt.Fprintf(&b, " Write the tests in %s, keeping any tests that are already there.", testPath)
+	}
+	return b.String()
+}
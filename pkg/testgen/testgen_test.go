@@ -0,0 +1,54 @@
+package testgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetLanguage(t *testing.T) {
+	tests := []struct {
+		path     string
+		testPath string
+		command  string
+		err      bool
+	}{
+		{"pkg/calc/tokens.go", "pkg/calc/tokens_test.go", "go test ./pkg/calc", false},
+		{"app/models.py", "app/test_models.py", "python -m pytest app/test_models.py", false},
+		{"src/parser.ts", "src/parser.test.ts", "npx jest src/parser.test.ts", false},
+		{"src/lib.rs", "src/lib.rs", "cargo test", false},
+		{"main.go", "main_test.go", "go test .", false},
+		{"/home/me/app/pkg/a.go", "/home/me/app/pkg/a_test.go", "go test /home/me/app/pkg", false},
+		{"my pkg/it's.py", "my pkg/test_it's.py", `python -m pytest 'my pkg/test_it'\''s.py'`, false},
+		{"pkg/calc/tokens_test.go", "", "", true},
+		{"src/parser.spec.js", "", "", true},
+		{"README.md", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			lang, err := GetLanguage(tt.path)
+			if (err != nil) != tt.err {
+				t.Fatalf("GetLanguage(%q) error = %v, want error %v", tt.path, err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if got := lang.TestPath(tt.path); got != tt.testPath {
+				t.Errorf("TestPath = %q, want %q", got, tt.testPath)
+			}
+			if got := lang.Command(tt.testPath); got != tt.command {
+				t.Errorf("Command = %q, want %q", got, tt.command)
+			}
+		})
+	}
+}
+
+func TestGoal(t *testing.T) {
+	lang, _ := GetLanguage("pkg/calc/tokens.go")
+	goal := lang.Goal("pkg/calc/tokens.go", "package calc\n\nfunc GetMaxTokens() {}\n", "GetMaxTokens")
+	for _, want := range []string{"the function GetMaxTokens", "The package is calc.", "pkg/calc/tokens_test.go"} {
+		if !strings.Contains(goal, want) {
+			t.Errorf("Goal is missing %q: %s", want, goal)
+		}
+	}
+}
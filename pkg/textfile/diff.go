@@ -0,0 +1,254 @@
+package textfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the number of unchanged lines shown around each change
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Diff returns a unified diff between the old and new contents, or an empty string
+// if they are the same. oldName and newName are used in the header, for example
+// a/main.go and b/main.go, or /dev/null for a file that is created.
+func Diff(oldName, newName, oldContents, newContents string) string {
+	if oldContents == newContents {
+		return ""
+	}
+
+	ops := diffLines(splitKeepNewlines(oldContents), splitKeepNewlines(newContents))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	// find the changes and group the ones close enough to share context
+	i := 0
+	for i < len(ops) {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// count the unchanged lines until the next change
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = next
+		}
+
+		writeHunk(&b, ops, start, end)
+		i = end
+	}
+
+	return b.String()
+}
+
+// writes the hunk for ops[start:end]
+func writeHunk(b *strings.Builder, ops []diffOp, start, end int) {
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+
+	// an empty range starts at the line before it
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+	for _, op := range ops[start:end] {
+		b.WriteByte(op.kind)
+		b.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(line, count int) string {
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splits the contents into lines, keeping the line endings so a
+// missing newline at the end of the file shows up in the diff
+func splitKeepNewlines(contents string) []string {
+	lines := strings.SplitAfter(contents, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines finds the shortest edit script from a to b using Myers' algorithm
+func diffLines(a, b []string) []diffOp {
+	// the common prefix and suffix don't need to go through the algorithm
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// maxTrace is the most entries of the trace myers keeps, about 32MB
+const maxTrace = 4 << 20
+
+// replaceAll removes all of a and adds all of b
+func replaceAll(a, b []string) []diffOp {
+	var ops []diffOp
+	for _, line := range a {
+		ops = append(ops, diffOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, diffOp{'+', line})
+	}
+	return ops
+}
+
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	// new and deleted files are all one op, without a trace to keep
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	// the furthest reaching paths before each step, used to walk back. Step d only reads the
+	// diagonals -(d-1) to d-1 of the step before it, so only those are kept.
+	var trace [][]int
+	traced := 0
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		if d == 0 {
+			trace = append(trace, nil)
+		} else {
+			trace = append(trace, append([]int(nil), v[offset-d+1:offset+d]...))
+			traced += 2*d - 1
+		}
+		// the trace grows with the square of the edits, so files that are mostly rewritten
+		// are shown as replaced instead
+		if traced > maxTrace {
+			return replaceAll(a, b)
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// walk back from the end, collecting the ops in reverse
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		band := trace[d]
+		// the furthest x on diagonal k before step d, 0 before the first step
+		at := func(k int) int {
+			if i := k + d - 1; i >= 0 && i < len(band) {
+				return band[i]
+			}
+			return 0
+		}
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{'+', b[y-1]})
+			} else {
+				ops = append(ops, diffOp{'-', a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,122 @@
+package textfile
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "No changes",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "Changed line",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			expected: `--- a/file
++++ b/file
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name: "Missing newline at end of file",
+			old:  "a\n",
+			new:  "a\nb",
+			expected: `--- a/file
++++ b/file
+@@ -1 +1,2 @@
+ a
++b
+\ No newline at end of file
+`,
+		},
+		{
+			name: "New file",
+			old:  "",
+			new:  "a\n",
+			expected: `--- a/file
++++ b/file
+@@ -0,0 +1 @@
++a
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Diff("a/file", "b/file", tc.old, tc.new)
+			if result != tc.expected {
+				t.Errorf("Expected diff:\n%s\nbut got:\n%s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+		var gotA, gotB []string
+		changed := 0
+		for _, op := range diffLines(a, b) {
+			if op.kind != '+' {
+				gotA = append(gotA, op.line)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.line)
+			}
+			if op.kind != ' ' {
+				changed++
+			}
+		}
+		if strings.Join(gotA, "\n") != strings.Join(a, "\n") || strings.Join(gotB, "\n") != strings.Join(b, "\n") {
+			t.Fatalf("the ops don't turn %q into %q", a, b)
+		}
+
+		// the shortest edit script changes every line outside the longest common subsequence
+		lcs := make([][]int, len(a)+1)
+		for x := range lcs {
+			lcs[x] = make([]int, len(b)+1)
+		}
+		for x := len(a) - 1; x >= 0; x-- {
+			for y := len(b) - 1; y >= 0; y-- {
+				if a[x] == b[y] {
+					lcs[x][y] = lcs[x+1][y+1] + 1
+				} else if lcs[x+1][y] > lcs[x][y+1] {
+					lcs[x][y] = lcs[x+1][y]
+				} else {
+					lcs[x][y] = lcs[x][y+1]
+				}
+			}
+		}
+		if want := len(a) + len(b) - 2*lcs[0][0]; changed != want {
+			t.Fatalf("expected %d changed lines from %q to %q, got %d", want, a, b, changed)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package textfile
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -56,3 +57,46 @@ func ReplaceLines(code string, startLine int, endLine int, newText string) (stri
 	lines = append(lines[:startLine-1], append([]string{newText}, lines[endLine:]...)...)
 	return strings.Join(lines, "\n"), nil
 }
+
+// Edit replaces the lines Start through End (1-indexed, inclusive) with Lines.
+// If End is less than Start, Lines are inserted before Start without removing anything.
+type Edit struct {
+	Start int
+	End   int
+	Lines []string
+}
+
+// ApplyEdits applies all the edits to the code. Line numbers refer to the original
+// code, so the edits do not need to account for each other. Edits may not overlap.
+func ApplyEdits(code string, edits []Edit) (string, error) {
+	lines := strings.Split(code, "\n")
+
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start > sorted[j].Start
+	})
+
+	for i, edit := range sorted {
+		if edit.Start < 1 || edit.Start > len(lines)+1 {
+			return "", fmt.Errorf("line %d is out of bounds", edit.Start)
+		}
+		if edit.End > len(lines) {
+			return "", fmt.Errorf("line %d is out of bounds", edit.End)
+		}
+		if i > 0 && edit.End >= sorted[i-1].Start {
+			return "", fmt.Errorf("edit at line %d overlaps edit at line %d", edit.Start, sorted[i-1].Start)
+		}
+
+		end := edit.End
+		if end < edit.Start {
+			end = edit.Start - 1
+		}
+
+		newLines := append([]string{}, lines[:edit.Start-1]...)
+		newLines = append(newLines, edit.Lines...)
+		lines = append(newLines, lines[end:]...)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
@@ -115,3 +115,71 @@ func TestInsertLine(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyEdits(t *testing.T) {
+	testCases := []struct {
+		name           string
+		code           string
+		edits          []Edit
+		expectedResult string
+		expectError    bool
+	}{
+		{
+			name: "Insert before lines",
+			code: "Line 1\nLine 2\nLine 3",
+			edits: []Edit{
+				{Start: 1, End: 0, Lines: []string{"// one"}},
+				{Start: 3, End: 2, Lines: []string{"// three", "// more"}},
+			},
+			expectedResult: "// one\nLine 1\nLine 2\n// three\n// more\nLine 3",
+			expectError:    false,
+		},
+		{
+			name: "Replace and insert",
+			code: "// old\n// doc\nLine 3\nLine 4",
+			edits: []Edit{
+				{Start: 4, End: 3, Lines: []string{"// four"}},
+				{Start: 1, End: 2, Lines: []string{"// new"}},
+			},
+			expectedResult: "// new\nLine 3\n// four\nLine 4",
+			expectError:    false,
+		},
+		{
+			name: "Overlapping edits",
+			code: "Line 1\nLine 2\nLine 3",
+			edits: []Edit{
+				{Start: 1, End: 2, Lines: []string{"a"}},
+				{Start: 2, End: 3, Lines: []string{"b"}},
+			},
+			expectedResult: "",
+			expectError:    true,
+		},
+		{
+			name: "Out of bounds",
+			code: "Line 1",
+			edits: []Edit{
+				{Start: 3, End: 2, Lines: []string{"a"}},
+			},
+			expectedResult: "",
+			expectError:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := ApplyEdits(tc.code, tc.edits)
+
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error, but got nil")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if result != tc.expectedResult {
+				t.Errorf("Expected result: %q, but got: %q", tc.expectedResult, result)
+			}
+		})
+	}
+}
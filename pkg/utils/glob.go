@@ -1,16 +1,105 @@
 package utils
 
-import "path/filepath"
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
 
-// GlobAll returns all files that match any of the given patterns
+// GlobAll returns all files that match any of the given patterns. Besides the patterns
+// filepath.Glob supports, ** matches any number of directories, for example pkg/**/*.go.
 func GlobAll(patterns []string) ([]string, error) {
 	var files []string
+	seen := map[string]bool{}
 	for _, pattern := range patterns {
-		matches, err := filepath.Glob(pattern)
+		var matches []string
+		var err error
+		if strings.Contains(pattern, "**") {
+			matches, err = globRecursive(pattern)
+		} else {
+			matches, err = filepath.Glob(pattern)
+		}
 		if err != nil {
 			return nil, err
 		}
-		files = append(files, matches...)
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
 	}
 	return files, nil
 }
+
+// globRecursive walks the directory the pattern starts in and matches each file against it
+func globRecursive(pattern string) ([]string, error) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+
+	// walk from the directories before the first part with a wildcard
+	var base []string
+	for _, part := range parts {
+		if strings.ContainsAny(part, "*?[") {
+			break
+		}
+		base = append(base, part)
+	}
+	root := "."
+	if len(base) > 0 {
+		root = strings.Join(base, "/")
+		if root == "" {
+			root = "/"
+		}
+	}
+
+	var matches []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				// like filepath.Glob, a missing directory matches nothing
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		ok, err := matchParts(parts, strings.Split(filepath.ToSlash(path), "/"))
+		if err != nil {
+			return err
+		}
+		if ok {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// matchParts matches the parts of a path against the parts of a pattern, where ** matches
+// any number of parts
+func matchParts(pattern, path []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				ok, err := matchParts(pattern[1:], path[i:])
+				if ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(path) == 0 {
+			return false, nil
+		}
+		ok, err := filepath.Match(pattern[0], path[0])
+		if !ok || err != nil {
+			return false, err
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0, nil
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Progress displays a live count of finished, failed and skipped items on a single line.
+// It is safe to use from multiple goroutines.
+type Progress struct {
+	mu       sync.Mutex
+	label    string
+	total    int
+	done     int
+	failed   int
+	skipped  int
+	out      io.Writer
+	terminal bool
+}
+
+// NewProgress creates a progress display for total items. Output goes to stderr so
+// it doesn't mix with output that may be piped.
+func NewProgress(label string, total int) *Progress {
+	terminal := false
+	if info, err := os.Stderr.Stat(); err == nil {
+		terminal = info.Mode()&os.ModeCharDevice != 0
+	}
+
+	p := &Progress{
+		label:    label,
+		total:    total,
+		out:      os.Stderr,
+		terminal: terminal,
+	}
+	p.render()
+	return p
+}
+
+// Done marks an item as finished successfully
+func (p *Progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.render()
+}
+
+// Failed marks an item as failed
+func (p *Progress) Failed() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failed++
+	p.render()
+}
+
+// Skipped marks an item as skipped
+func (p *Progress) Skipped() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.skipped++
+	p.render()
+}
+
+// Counts returns the number of finished, failed and skipped items
+func (p *Progress) Counts() (int, int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done, p.failed, p.skipped
+}
+
+// Finish ends the progress line
+func (p *Progress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.terminal {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// must be called with the lock held
+func (p *Progress) render() {
+	// only redraw in place on a terminal, otherwise it would flood logs
+	if !p.terminal {
+		return
+	}
+	finished := p.done + p.failed + p.skipped
+	fmt.Fprintf(p.out, "\r\033[K%s %d/%d (%d done, %d failed, %d skipped)", p.label, finished, p.total, p.done, p.failed, p.skipped)
+}
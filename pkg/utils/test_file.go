@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"path"
+	"strings"
+)
+
+// IsTestFile reports whether the file holds tests by the naming conventions of Go,
+// Python and JavaScript: x_test.go, test_x.py, x_test.py, x.test.js and x.spec.js
+func IsTestFile(p string) bool {
+	base := path.Base(strings.ReplaceAll(p, "\\", "/"))
+	ext := strings.ToLower(path.Ext(base))
+	switch {
+	case ext == ".go":
+		return strings.HasSuffix(base, "_test.go")
+	case ext == ".py":
+		return strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py")
+	}
+	return strings.Contains(base, ".test.") || strings.Contains(base, ".spec.")
+}
@@ -0,0 +1,317 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/TimeSurgeLabs/ottodocs/pkg/journal"
+	"github.com/TimeSurgeLabs/ottodocs/pkg/textfile"
+	"github.com/charmbracelet/log"
+)
+
+// package for writing files, shared by every command that changes files on disk
+
+// the extension of the backup made of a file before it is replaced
+const BackupExt = ".orig"
+
+// Writer writes files to disk, or instead prints or saves the changes as a unified diff.
+// It is safe to use from multiple goroutines.
+type Writer struct {
+	// print the diff instead of writing the files
+	DryRun bool
+	// save the diff to this patch file instead of writing the files
+	PatchFile string
+	// keep a copy of the original file with BackupExt when it is replaced
+	Backup bool
+	// where dry run diffs are printed, defaults to stdout
+	Out io.Writer
+	// if set, the files written are recorded in the journal so they can be undone
+	Journal *journal.Entry
+
+	mu sync.Mutex
+	// the new contents of the files that were not written, in the order they were changed
+	pending map[string]string
+	order   []string
+}
+
+// New creates a writer with the options
+func New(dryRun bool, patchFile string, backup bool) *Writer {
+	return &Writer{
+		DryRun:    dryRun,
+		PatchFile: patchFile,
+		Backup:    backup,
+		Out:       os.Stdout,
+		pending:   map[string]string{},
+	}
+}
+
+// Writes reports whether the writer changes the files, rather than only showing the changes
+func (w *Writer) Writes() bool {
+	return !w.DryRun && w.PatchFile == ""
+}
+
+// WriteFile replaces the contents of the file, creating it if it doesn't exist
+func (w *Writer) WriteFile(path, contents string) error {
+	return w.write(path, func(string) string { return contents })
+}
+
+// AppendFile adds the contents to the end of the file, creating it if it doesn't exist
+func (w *Writer) AppendFile(path, contents string) error {
+	return w.write(path, func(old string) string { return old + contents })
+}
+
+func (w *Writer) write(path string, change func(string) string) error {
+	path = filepath.Clean(path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.Writes() {
+		old, exists, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		contents := change(old)
+		err = Atomic(path, contents, w.Backup && exists)
+		if err != nil {
+			return err
+		}
+		if w.Journal != nil {
+			w.record(path, old, exists, contents)
+		}
+		return nil
+	}
+
+	// later changes to the same file build on the earlier ones
+	old, ok := w.pending[path]
+	if !ok {
+		var err error
+		old, _, err = readFile(path)
+		if err != nil {
+			return err
+		}
+		w.order = append(w.order, path)
+	}
+	w.pending[path] = change(old)
+	return nil
+}
+
+// WriteFiles replaces the contents of the files, by path, all together. If any file can't be
+// written, the ones that were are restored along with their backups, so either every file
+// changes or none do. The error lists any file that couldn't be restored.
+func (w *Writer) WriteFiles(files map[string]string) error {
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if !w.Writes() {
+		for _, path := range paths {
+			err := w.WriteFile(path, files[path])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	type original struct {
+		path     string
+		contents string
+		exists   bool
+		// the backup this call replaces, so it can be put back
+		backup       string
+		backupExists bool
+	}
+	var originals []original
+	for _, path := range paths {
+		o := original{path: filepath.Clean(path)}
+		var err error
+		o.contents, o.exists, err = readFile(o.path)
+		if err != nil {
+			return err
+		}
+		if w.Backup && o.exists {
+			o.backup, o.backupExists, err = readFile(o.path + BackupExt)
+			if err != nil {
+				return err
+			}
+		}
+		originals = append(originals, o)
+	}
+
+	for i, o := range originals {
+		err := Atomic(o.path, files[paths[i]], w.Backup && o.exists)
+		if err == nil {
+			continue
+		}
+
+		// the file that failed may have been backed up before it failed
+		var modified []string
+		for j, written := range originals[:i+1] {
+			if j < i && restore(written.path, written.contents, written.exists) != nil {
+				modified = append(modified, written.path)
+			}
+			if w.Backup && written.exists && restore(written.path+BackupExt, written.backup, written.backupExists) != nil {
+				modified = append(modified, written.path+BackupExt)
+			}
+		}
+		if len(modified) > 0 {
+			return fmt.Errorf("could not write %s: %s. These files could not be restored and were left modified: %s", o.path, err, strings.Join(modified, ", "))
+		}
+		return fmt.Errorf("could not write %s, no files were changed: %s", o.path, err)
+	}
+
+	if w.Journal != nil {
+		for i, o := range originals {
+			w.record(o.path, o.contents, o.exists, files[paths[i]])
+		}
+	}
+	return nil
+}
+
+// record adds the written file to the journal. The file has already been written, so
+// a failure is only a warning that it can't be undone.
+func (w *Writer) record(path, original string, existed bool, contents string) {
+	err := w.Journal.Record(path, original, existed, contents)
+	if err != nil {
+		log.Warnf("Could not record %s in the journal, it can't be undone with otto undo: %s", path, err)
+	}
+}
+
+// Diff returns the unified diff of the changes that were not written
+func (w *Writer) Diff() (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var b strings.Builder
+	for _, path := range w.order {
+		old, exists, err := readFile(path)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(textfile.Diff(diffName("a", path, exists), diffName("b", path, true), old, w.pending[path]))
+	}
+	return b.String(), nil
+}
+
+// Flush prints the diff for a dry run or saves the patch file. Must be called after all the files are written.
+func (w *Writer) Flush() error {
+	if w.Writes() {
+		return nil
+	}
+
+	diff, err := w.Diff()
+	if err != nil {
+		return err
+	}
+
+	if w.DryRun {
+		_, err = io.WriteString(w.Out, diff)
+		return err
+	}
+	return os.WriteFile(w.PatchFile, []byte(diff), 0644)
+}
+
+// puts back the contents of a file, removing it if it didn't exist
+func restore(path, contents string, exists bool) error {
+	if exists {
+		return Atomic(path, contents, false)
+	}
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// reads the file, a missing file is empty
+func readFile(path string) (string, bool, error) {
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return string(contents), true, nil
+}
+
+// Atomic writes the file by writing a temporary file next to it and renaming it over the
+// original, so the file is never left half written. Missing directories are created. If backup is true the original is
+// copied to the path with BackupExt first.
+func Atomic(path, contents string, backup bool) error {
+	mode := os.FileMode(0644)
+	info, err := os.Stat(path)
+	if err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if backup && info != nil {
+		old, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(path+BackupExt, old, mode)
+		if err != nil {
+			return fmt.Errorf("could not back up %s: %s", path, err)
+		}
+	}
+
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+".otto-*")
+	if err != nil {
+		return err
+	}
+	// remove the temp file if anything goes wrong before the rename
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(contents)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Chmod(mode)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// the name of the file in the diff header, relative to the working directory like git
+func diffName(prefix, path string, exists bool) string {
+	if !exists {
+		return "/dev/null"
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return prefix + "/" + filepath.ToSlash(path)
+}
@@ -0,0 +1,90 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	err := os.WriteFile(path, []byte("a\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a dry run leaves the file alone and shows every change to it
+	var out strings.Builder
+	w := New(true, "", false)
+	w.Out = &out
+	if err := w.AppendFile(path, "b\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AppendFile(path, "c\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, _ := os.ReadFile(path)
+	if string(contents) != "a\n" {
+		t.Errorf("Expected the file to be unchanged, but got %q", contents)
+	}
+	if !strings.Contains(out.String(), "+b\n+c\n") {
+		t.Errorf("Expected the diff to contain both changes, but got:\n%s", out.String())
+	}
+
+	// writing replaces the file and keeps a backup
+	w = New(false, "", true)
+	if err := w.WriteFile(path, "new\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, _ = os.ReadFile(path)
+	if string(contents) != "new\n" {
+		t.Errorf("Expected the file to be written, but got %q", contents)
+	}
+	backup, _ := os.ReadFile(path + BackupExt)
+	if string(backup) != "a\n" {
+		t.Errorf("Expected the backup to contain the original, but got %q", backup)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("Expected only the file and its backup, but found %d files", len(entries))
+	}
+}
+
+func TestWriteFilesRollback(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.txt")
+	err := os.WriteFile(first, []byte("a\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second file is under a regular file, so it can't be written
+	w := New(false, "", true)
+	err = w.WriteFiles(map[string]string{
+		first:                          "changed\n",
+		filepath.Join(first, "b.txt"):  "b\n",
+		filepath.Join(dir, "0new.txt"): "new\n",
+	})
+	if err == nil {
+		t.Fatal("Expected an error writing under a file")
+	}
+
+	contents, _ := os.ReadFile(first)
+	if string(contents) != "a\n" {
+		t.Errorf("Expected the written file to be restored, but got %q", contents)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "0new.txt")); !os.IsNotExist(err) {
+		t.Error("Expected the created file to be removed")
+	}
+	if _, err := os.Stat(first + BackupExt); !os.IsNotExist(err) {
+		t.Error("Expected the backup to be removed")
+	}
+}